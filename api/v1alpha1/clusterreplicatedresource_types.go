@@ -0,0 +1,131 @@
+/*
+Copyright 2021-2022 Russell Sim <russell.sim@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClusterReplicatedResourceDestination selects the namespaces that the
+// source is replicated to. A namespace is selected when it matches the
+// NamespaceSelector or is listed in Namespaces, and isn't listed in
+// ExcludedNamespaces. The name defaults to the name of the
+// ClusterReplicatedResource.
+type ClusterReplicatedResourceDestination struct {
+	ReplicatedResourceDestination `json:",inline"`
+
+	// NamespaceSelector selects namespaces by label, an empty selector
+	// selects every namespace.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	// Namespaces is an explicit list of namespaces.
+	// +optional
+	Namespaces []string `json:"namespaces,omitempty"`
+	// ExcludedNamespaces are never replicated to.
+	// +optional
+	ExcludedNamespaces []string `json:"excludedNamespaces,omitempty"`
+}
+
+// ClusterReplicatedResourceSpec defines the desired state of ClusterReplicatedResource
+type ClusterReplicatedResourceSpec struct {
+	Source ReplicatedResourceSource `json:"source,omitempty"`
+
+	// Fields lists the top-level fields of the source object that are
+	// copied to the destination, see ReplicatedResourceSpec.
+	// +optional
+	Fields []string `json:"fields,omitempty"`
+
+	Destination ClusterReplicatedResourceDestination `json:"destination,omitempty"`
+
+	// DriftPolicy controls what happens when a copy has been modified
+	// since it was last replicated, defaults to Correct.
+	// +optional
+	DriftPolicy DriftPolicy `json:"driftPolicy,omitempty"`
+
+	// DeletionPolicy controls what happens to the copies when the
+	// ClusterReplicatedResource is deleted, defaults to Delete.
+	// +optional
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// ConflictPolicy controls what happens when a copy already exists and
+	// isn't controlled by the ClusterReplicatedResource, defaults to Fail.
+	// +optional
+	ConflictPolicy ConflictPolicy `json:"conflictPolicy,omitempty"`
+
+	// OnSourceDeleted controls what happens to the copies when the source
+	// is deleted, defaults to Retain.
+	// +optional
+	OnSourceDeleted SourceDeletionPolicy `json:"onSourceDeleted,omitempty"`
+
+	// SourceDeletedGracePeriod is how long the RetainFor policy keeps the
+	// copies after the source was deleted, defaults to 24h.
+	// +optional
+	SourceDeletedGracePeriod *metav1.Duration `json:"sourceDeletedGracePeriod,omitempty"`
+
+	// Transform modifies the data of Secrets and ConfigMaps.
+	// +optional
+	Transform ReplicatedResourceTransform `json:"transform,omitempty"`
+}
+
+// ClusterReplicatedResourceStatus defines the observed state of ClusterReplicatedResource
+type ClusterReplicatedResourceStatus struct {
+	Phase string `json:"phase,omitempty"`
+	// ObservedGeneration is the generation of the spec that was last
+	// reconciled.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+	// Namespaces that the source is currently replicated to.
+	// +optional
+	Namespaces []string `json:"namespaces,omitempty"`
+	// SourceNotFoundSince is when the source was first found missing, it
+	// is cleared once the source is found again.
+	// +optional
+	SourceNotFoundSince *metav1.Time `json:"sourceNotFoundSince,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:scope=Cluster
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].reason`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
+
+// ClusterReplicatedResource is the Schema for the clusterreplicatedresources API
+type ClusterReplicatedResource struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   ClusterReplicatedResourceSpec   `json:"spec,omitempty"`
+	Status ClusterReplicatedResourceStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ClusterReplicatedResourceList contains a list of ClusterReplicatedResource
+type ClusterReplicatedResourceList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ClusterReplicatedResource `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ClusterReplicatedResource{}, &ClusterReplicatedResourceList{})
+}
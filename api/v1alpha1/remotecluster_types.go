@@ -0,0 +1,78 @@
+/*
+Copyright 2021-2022 Russell Sim <russell.sim@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// KubeconfigSecretReference references the key of a Secret that holds a
+// kubeconfig.
+type KubeconfigSecretReference struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// Key of the kubeconfig in the Secret, defaults to kubeconfig.
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
+// RemoteClusterAllowedNamespaces selects the namespaces of the
+// ReplicatedResources that can replicate to a RemoteCluster. A namespace is
+// selected when it matches the NamespaceSelector or is listed in
+// Namespaces, none is selected when both are empty.
+type RemoteClusterAllowedNamespaces struct {
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	// +optional
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+// RemoteClusterSpec defines the desired state of RemoteCluster
+type RemoteClusterSpec struct {
+	// KubeconfigSecretRef references the kubeconfig used to connect to the
+	// cluster, with its current context.
+	KubeconfigSecretRef KubeconfigSecretReference `json:"kubeconfigSecretRef"`
+	// AllowedNamespaces selects the namespaces whose ReplicatedResources
+	// can replicate to the cluster, with the credentials of the
+	// kubeconfig. No ReplicatedResource can when it's unset.
+	// +optional
+	AllowedNamespaces *RemoteClusterAllowedNamespaces `json:"allowedNamespaces,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+
+// RemoteCluster is the Schema for the remoteclusters API
+type RemoteCluster struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec RemoteClusterSpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// RemoteClusterList contains a list of RemoteCluster
+type RemoteClusterList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RemoteCluster `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&RemoteCluster{}, &RemoteClusterList{})
+}
@@ -19,61 +19,442 @@ package v1alpha1
 import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
 )
 
-// ReplicatedResourceSpec defines the desired state of ReplicatedResource
+// ReplicatedResourceSource identifies the object that is replicated
 type ReplicatedResourceSource struct {
 	Namespace string `json:"namespace,omitempty"`
 	Name      string `json:"name,omitempty"`
-	Kind      string `json:"kind,omitempty"`
+	// APIVersion of the source object, defaults to v1 (the core API group).
+	// +optional
+	APIVersion string `json:"apiVersion,omitempty"`
+	Kind       string `json:"kind,omitempty"`
+	// ClusterRef reads the source from another cluster instead of the local
+	// one. Only ReplicatedResources support it.
+	// +optional
+	ClusterRef *SourceClusterReference `json:"clusterRef,omitempty"`
+}
+
+// SourceClusterReference references the key of a Secret, in the namespace
+// of the ReplicatedResource, that holds the kubeconfig of the cluster of a
+// source.
+type SourceClusterReference struct {
+	// Name of the Secret.
+	Name string `json:"name"`
+	// Key of the kubeconfig in the Secret, defaults to kubeconfig.
+	// +optional
+	Key string `json:"key,omitempty"`
+}
+
+// GroupVersionKind returns the GroupVersionKind of the source object,
+// defaulting to the core v1 API when no APIVersion is set.
+func (s ReplicatedResourceSource) GroupVersionKind() schema.GroupVersionKind {
+	if s.APIVersion == "" {
+		return corev1.SchemeGroupVersion.WithKind(s.Kind)
+	}
+	return schema.FromAPIVersionAndKind(s.APIVersion, s.Kind)
 }
 
+// AllSources returns the sources of the spec, which is Source unless
+// Sources is set.
+func (s *ReplicatedResourceSpec) AllSources() []ReplicatedResourceSource {
+	if len(s.Sources) == 0 {
+		return []ReplicatedResourceSource{s.Source}
+	}
+	sources := make([]ReplicatedResourceSource, len(s.Sources))
+	for i, source := range s.Sources {
+		sources[i] = source.ReplicatedResourceSource
+	}
+	return sources
+}
+
+// ReplicatedResourceMergeSource is a Secret or ConfigMap whose data is
+// merged into the destination
+type ReplicatedResourceMergeSource struct {
+	ReplicatedResourceSource `json:",inline"`
+	// KeyPrefix is prepended to the keys of the source.
+	// +optional
+	KeyPrefix string `json:"keyPrefix,omitempty"`
+}
+
+// KeyConflictPolicy controls which value is replicated when merged sources
+// have the same key.
+// +kubebuilder:validation:Enum=FirstWins;LastWins;Error
+type KeyConflictPolicy string
+
+const (
+	// KeyConflictFirstWins replicates the value of the first source.
+	KeyConflictFirstWins KeyConflictPolicy = "FirstWins"
+	// KeyConflictLastWins replicates the value of the last source.
+	KeyConflictLastWins KeyConflictPolicy = "LastWins"
+	// KeyConflictError fails the replication.
+	KeyConflictError KeyConflictPolicy = "Error"
+)
+
+// SourceMetadataPolicy controls which labels and annotations of the source
+// are copied to the destination.
+// +kubebuilder:validation:Enum=All;AllowList;None
+type SourceMetadataPolicy string
+
+const (
+	// SourceMetadataAll copies every label and annotation.
+	SourceMetadataAll SourceMetadataPolicy = "All"
+	// SourceMetadataAllowList copies the labels and annotations with a
+	// key matching one of the allowed prefixes.
+	SourceMetadataAllowList SourceMetadataPolicy = "AllowList"
+	// SourceMetadataNone doesn't copy any labels or annotations.
+	SourceMetadataNone SourceMetadataPolicy = "None"
+)
+
+// SourceMetadata selects the labels and annotations copied from the source
+type SourceMetadata struct {
+	// Policy for copying source labels and annotations, defaults to None.
+	// +optional
+	Policy SourceMetadataPolicy `json:"policy,omitempty"`
+	// Prefixes of the keys copied by the AllowList policy.
+	// +optional
+	Prefixes []string `json:"prefixes,omitempty"`
+}
+
+// ReplicatedResourceDestination configures the replicated object
+type ReplicatedResourceDestination struct {
+	// Name of the destination, defaults to the name of the ReplicatedResource.
+	// +optional
+	Name string `json:"name,omitempty"`
+	// Labels added to the destination.
+	// +optional
+	Labels map[string]string `json:"labels,omitempty"`
+	// Annotations added to the destination.
+	// +optional
+	Annotations map[string]string `json:"annotations,omitempty"`
+	// SourceMetadata selects the labels and annotations of the source that
+	// are copied to the destination, Labels and Annotations take precedence.
+	// +optional
+	SourceMetadata SourceMetadata `json:"sourceMetadata,omitempty"`
+	// Clusters selects the RemoteClusters that the destination is also
+	// replicated to, in the namespace of the ReplicatedResource. Only the
+	// clusters whose allowedNamespaces select that namespace are replicated
+	// to. The consent of the source is checked against the namespace in the
+	// local cluster, not in the remote clusters.
+	// +optional
+	Clusters *ClusterSelector `json:"clusters,omitempty"`
+}
+
+// ClusterSelector selects RemoteClusters. A cluster is selected when it is
+// listed in Names or matches Selector.
+type ClusterSelector struct {
+	// +optional
+	Names []string `json:"names,omitempty"`
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+}
+
+// KeyTransform filters and renames the keys of Secret and ConfigMap data.
+// A key is replicated when it is renamed, or matches one of the Include
+// patterns or Include is empty, and it doesn't match any of the Exclude
+// patterns. Patterns use the syntax of path.Match.
+type KeyTransform struct {
+	// Include lists the patterns of the keys to replicate. Keys listed
+	// without wildcards must exist in the source.
+	// +optional
+	Include []string `json:"include,omitempty"`
+	// Exclude lists the patterns of the keys that are never replicated.
+	// +optional
+	Exclude []string `json:"exclude,omitempty"`
+	// Rename maps source keys, which must exist in the source, to the key
+	// they are replicated as.
+	// +optional
+	Rename map[string]string `json:"rename,omitempty"`
+}
+
+// ReplicatedResourceTransform modifies the replicated data
+type ReplicatedResourceTransform struct {
+	// +optional
+	Keys *KeyTransform `json:"keys,omitempty"`
+	// Template maps destination keys to Go text/template strings that are
+	// evaluated against the source, as .Data and .Metadata. Rendered keys
+	// replace replicated keys of the same name.
+	// +optional
+	Template map[string]string `json:"template,omitempty"`
+}
+
+// ReplicatedResourceRollout selects the Deployments, StatefulSets and
+// DaemonSets of the destination namespace that are restarted when the
+// content of the destination changes. A workload is selected when it
+// matches Selector or, with AutoDetect, references the destination.
+type ReplicatedResourceRollout struct {
+	// +optional
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+	// AutoDetect selects the workloads whose pod template references the
+	// destination, a Secret or ConfigMap, in a volume, env or envFrom.
+	// +optional
+	AutoDetect bool `json:"autoDetect,omitempty"`
+}
+
+// DriftPolicy controls what happens when a destination has been modified
+// since it was last replicated.
+// +kubebuilder:validation:Enum=Correct;ReportOnly;Ignore
+type DriftPolicy string
+
+const (
+	// DriftCorrect overwrites the destination and reports the drift.
+	DriftCorrect DriftPolicy = "Correct"
+	// DriftReportOnly reports the drift without changing the destination.
+	DriftReportOnly DriftPolicy = "ReportOnly"
+	// DriftIgnore doesn't check the destination for drift.
+	DriftIgnore DriftPolicy = "Ignore"
+)
+
+// DeletionPolicy controls what happens to the destinations when their
+// ReplicatedResource or ClusterReplicatedResource is deleted.
+// +kubebuilder:validation:Enum=Delete;Orphan
+type DeletionPolicy string
+
+const (
+	// DeletionDelete deletes the destinations.
+	DeletionDelete DeletionPolicy = "Delete"
+	// DeletionOrphan keeps the destinations and removes the owner
+	// reference and the annotations of the operator from them.
+	DeletionOrphan DeletionPolicy = "Orphan"
+)
+
+// SourceDeletionPolicy controls what happens to the destinations when the
+// source is deleted.
+// +kubebuilder:validation:Enum=Retain;Delete;RetainFor
+type SourceDeletionPolicy string
+
+const (
+	// SourceDeletedRetain keeps the last copy and reports the missing
+	// source.
+	SourceDeletedRetain SourceDeletionPolicy = "Retain"
+	// SourceDeletedDelete deletes the destinations.
+	SourceDeletedDelete SourceDeletionPolicy = "Delete"
+	// SourceDeletedRetainFor keeps the last copy for the grace period and
+	// deletes it afterwards.
+	SourceDeletedRetainFor SourceDeletionPolicy = "RetainFor"
+)
+
+// ConflictPolicy controls what happens when a destination already exists
+// and isn't controlled by its ReplicatedResource or
+// ClusterReplicatedResource.
+// +kubebuilder:validation:Enum=Fail;Adopt;AdoptIfMatchingLabel
+type ConflictPolicy string
+
+const (
+	// ConflictFail leaves the destination alone and fails the replication.
+	ConflictFail ConflictPolicy = "Fail"
+	// ConflictAdopt takes over a destination that has no controller.
+	ConflictAdopt ConflictPolicy = "Adopt"
+	// ConflictAdoptIfMatchingLabel takes over a destination that has no
+	// controller if its replicated-resource.simopolis.xyz/adopt label is
+	// the name of the adopting object.
+	ConflictAdoptIfMatchingLabel ConflictPolicy = "AdoptIfMatchingLabel"
+)
+
 // ReplicatedResourceSpec defines the desired state of ReplicatedResource
 type ReplicatedResourceSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
 
 	Source ReplicatedResourceSource `json:"source,omitempty"`
+
+	// Sources lists the Secrets and ConfigMaps whose data is merged, in
+	// order, into the destination instead of replicating Source. The
+	// destination is of the kind of the first source, whose metadata is
+	// copied.
+	// +optional
+	Sources []ReplicatedResourceMergeSource `json:"sources,omitempty"`
+
+	// KeyConflictPolicy controls which value is replicated when Sources
+	// have the same key, defaults to Error.
+	// +optional
+	KeyConflictPolicy KeyConflictPolicy `json:"keyConflictPolicy,omitempty"`
+
+	// Retargetable allows Source and Sources to be changed once the
+	// ReplicatedResource is created, they are immutable otherwise.
+	// +optional
+	Retargetable bool `json:"retargetable,omitempty"`
+
+	// +optional
+	Destination ReplicatedResourceDestination `json:"destination,omitempty"`
+
+	// DriftPolicy controls what happens when the destination has been
+	// modified since it was last replicated, defaults to Correct.
+	// +optional
+	DriftPolicy DriftPolicy `json:"driftPolicy,omitempty"`
+
+	// DeletionPolicy controls what happens to the destination when the
+	// ReplicatedResource is deleted, defaults to Delete.
+	// +optional
+	DeletionPolicy DeletionPolicy `json:"deletionPolicy,omitempty"`
+
+	// ConflictPolicy controls what happens when the destination already
+	// exists and isn't controlled by the ReplicatedResource, defaults to
+	// Fail.
+	// +optional
+	ConflictPolicy ConflictPolicy `json:"conflictPolicy,omitempty"`
+
+	// OnSourceDeleted controls what happens to the destination when the
+	// source is deleted, defaults to Retain.
+	// +optional
+	OnSourceDeleted SourceDeletionPolicy `json:"onSourceDeleted,omitempty"`
+
+	// SourceDeletedGracePeriod is how long the RetainFor policy keeps the
+	// destination after the source was deleted, defaults to 24h.
+	// +optional
+	SourceDeletedGracePeriod *metav1.Duration `json:"sourceDeletedGracePeriod,omitempty"`
+
+	// Fields lists the top-level fields of the source object that are
+	// copied to the destination, e.g. spec or data. Only used for kinds
+	// without a dedicated replicator. Defaults to every top-level field
+	// except the server managed metadata and status.
+	// +optional
+	Fields []string `json:"fields,omitempty"`
+
+	// Transform modifies the data of Secrets and ConfigMaps.
+	// +optional
+	Transform ReplicatedResourceTransform `json:"transform,omitempty"`
+
+	// Rollout restarts the workloads that consume the destination when
+	// its content changes, by patching the
+	// replicated-resource.simopolis.xyz/checksum annotation of their pod
+	// template.
+	// +optional
+	Rollout *ReplicatedResourceRollout `json:"rollout,omitempty"`
+}
+
+// These are the condition types of ReplicatedResources and
+// ClusterReplicatedResources.
+const (
+	// ReplicatedResourceReady means the source is replicated and the
+	// destinations are up to date.
+	ReplicatedResourceReady = "Ready"
+	// ReplicatedResourceSourceFound means the sources exist.
+	ReplicatedResourceSourceFound = "SourceFound"
+	// ReplicatedResourceSynced means the last replication succeeded.
+	ReplicatedResourceSynced = "Synced"
+	// ReplicatedResourceAuthorized means the sources allow the replication,
+	// ReplicationPolicies permit it and the creator can read the sources.
+	ReplicatedResourceAuthorized = "Authorized"
+	// ReplicatedResourceDrifted means the destination was modified after it was replicated.
+	ReplicatedResourceDrifted = "Drifted"
+	// ReplicatedResourceDestinationConflict means a destination exists and
+	// isn't controlled by its ReplicatedResource or
+	// ClusterReplicatedResource.
+	ReplicatedResourceDestinationConflict = "DestinationConflict"
+)
+
+// SourceRevision identifies the revision of a source that was replicated.
+type SourceRevision struct {
+	APIVersion string `json:"apiVersion,omitempty"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace"`
+	Name       string `json:"name"`
+	// +optional
+	UID types.UID `json:"uid,omitempty"`
+	// +optional
+	ResourceVersion string `json:"resourceVersion,omitempty"`
 }
 
-type ReplicatedResourceConditionType string
+// DestinationState is the state of a single destination.
+// +kubebuilder:validation:Enum=Synced;Drifted;Failed
+type DestinationState string
 
-// These are valid conditions of a ReplicatedResource.
 const (
-	// ReplicatedResourceComplete means the ReplicatedResource has completed its execution.
-	ReplicatedResourceComplete ReplicatedResourceConditionType = "Complete"
-	// ReplicatedResourceFailed means the ReplicatedResource has failed its execution.
-	ReplicatedResourceFailed ReplicatedResourceConditionType = "Failed"
+	// DestinationSynced means the destination matches the source.
+	DestinationSynced DestinationState = "Synced"
+	// DestinationDrifted means the destination was modified and left
+	// alone because of the drift policy.
+	DestinationDrifted DestinationState = "Drifted"
+	// DestinationFailed means the destination could not be replicated.
+	DestinationFailed DestinationState = "Failed"
 )
 
-// ReplicatedResourceCondition describes current state of a ReplicatedResource.
-type ReplicatedResourceCondition struct {
-	// Type of ReplicatedResource condition, Complete or Failed.
-	Type ReplicatedResourceConditionType `json:"type" protobuf:"bytes,1,opt,name=type,casttype=ReplicatedResourceConditionType"`
-	// Status of the condition, one of True, False, Unknown.
-	Status corev1.ConditionStatus `json:"status" protobuf:"bytes,2,opt,name=status,casttype=k8s.io/api/core/v1.ConditionStatus"`
-	// Last time the condition was checked.
+// DestinationStatus describes a destination object and its sync state.
+type DestinationStatus struct {
+	// Cluster is the name of the RemoteCluster holding the destination,
+	// empty for the local cluster.
+	// +optional
+	Cluster    string `json:"cluster,omitempty"`
+	APIVersion string `json:"apiVersion,omitempty"`
+	Kind       string `json:"kind"`
+	Namespace  string `json:"namespace"`
+	Name       string `json:"name"`
+	// +optional
+	UID   types.UID        `json:"uid,omitempty"`
+	State DestinationState `json:"state"`
+	// Message explains why the destination failed.
+	// +optional
+	Message string `json:"message,omitempty"`
+	// ContentHash is the SHA-256 of the content replicated to the
+	// destination.
 	// +optional
-	LastProbeTime metav1.Time `json:"lastProbeTime,omitempty" protobuf:"bytes,3,opt,name=lastProbeTime"`
-	// Last time the condition transit from one status to another.
+	ContentHash string `json:"contentHash,omitempty"`
+	// LastSyncTime is the last time the destination was replicated.
 	// +optional
-	LastTransitionTime metav1.Time `json:"lastTransitionTime,omitempty" protobuf:"bytes,4,opt,name=lastTransitionTime"`
-	// (brief) reason for the condition's last transition.
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+}
+
+// RolloutStatus is the progress of restarting the workloads that consume
+// the destination.
+type RolloutStatus struct {
+	// ContentHash is the content hash of the destination the workloads
+	// are restarted for.
 	// +optional
-	Reason string `json:"reason,omitempty" protobuf:"bytes,5,opt,name=reason"`
-	// Human readable message indicating details about last transition.
+	ContentHash string `json:"contentHash,omitempty"`
+	// Pending lists the workloads that are still to be restarted, as
+	// Kind/name.
 	// +optional
-	Message string `json:"message,omitempty" protobuf:"bytes,6,opt,name=message"`
+	Pending []string `json:"pending,omitempty"`
+	// LastRestartTime is when a workload was last restarted.
+	// +optional
+	LastRestartTime *metav1.Time `json:"lastRestartTime,omitempty"`
 }
 
 // ReplicatedResourceStatus defines the observed state of ReplicatedResource
 type ReplicatedResourceStatus struct {
-	Phase      string                        `json:"phase,omitempty"`
-	Conditions []ReplicatedResourceCondition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type" protobuf:"bytes,1,rep,name=conditions"`
+	Phase string `json:"phase,omitempty"`
+	// ObservedGeneration is the generation of the spec that was last
+	// reconciled.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Sources are the revisions of the sources that were last replicated.
+	// +optional
+	Sources []SourceRevision `json:"sources,omitempty"`
+	// ContentHash is the SHA-256 of the content that was last replicated,
+	// the replicated data itself is never part of the status.
+	// +optional
+	ContentHash string `json:"contentHash,omitempty"`
+	// Destinations are the objects replicated to.
+	// +optional
+	Destinations []DestinationStatus `json:"destinations,omitempty"`
+	// LastSyncTime is the last time replication was attempted.
+	// +optional
+	LastSyncTime *metav1.Time `json:"lastSyncTime,omitempty"`
+	// LastSuccessfulSyncTime is the last time replication succeeded.
+	// +optional
+	LastSuccessfulSyncTime *metav1.Time `json:"lastSuccessfulSyncTime,omitempty"`
+	// SourceNotFoundSince is when the source was first found missing, it
+	// is cleared once the source is found again.
+	// +optional
+	SourceNotFoundSince *metav1.Time `json:"sourceNotFoundSince,omitempty"`
+	// Rollout is the progress of restarting the workloads selected by
+	// spec.rollout.
+	// +optional
+	Rollout *RolloutStatus `json:"rollout,omitempty"`
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
 }
 
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Ready",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].status`
+// +kubebuilder:printcolumn:name="Reason",type=string,JSONPath=`.status.conditions[?(@.type=="Ready")].reason`
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // ReplicatedResource is the Schema for the replicatedresources API
 type ReplicatedResource struct {
@@ -84,6 +465,15 @@ type ReplicatedResource struct {
 	Status ReplicatedResourceStatus `json:"status,omitempty"`
 }
 
+// DestinationName returns the name of the destination, which defaults to
+// the name of the ReplicatedResource.
+func (rr *ReplicatedResource) DestinationName() string {
+	if rr.Spec.Destination.Name != "" {
+		return rr.Spec.Destination.Name
+	}
+	return rr.Name
+}
+
 // +kubebuilder:object:root=true
 
 // ReplicatedResourceList contains a list of ReplicatedResource
@@ -0,0 +1,111 @@
+/*
+Copyright 2021-2022 Russell Sim <russell.sim@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ReplicationPolicySource selects sources by namespace, name and kind. An
+// empty field matches every source.
+type ReplicationPolicySource struct {
+	// NamespaceSelector selects the namespaces of the sources by label.
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	// Namespaces lists the namespaces of the sources.
+	// +optional
+	Namespaces []string `json:"namespaces,omitempty"`
+	// Names lists patterns, in the syntax of path.Match, of source names.
+	// +optional
+	Names []string `json:"names,omitempty"`
+	// Kinds lists group qualified kinds, e.g. Secret or
+	// NetworkPolicy.networking.k8s.io.
+	// +optional
+	Kinds []string `json:"kinds,omitempty"`
+}
+
+// ReplicationPolicyDestination selects the namespaces that sources can be
+// replicated to. A namespace is selected when it matches the
+// NamespaceSelector or is listed in Namespaces, every namespace is
+// selected when both are empty.
+type ReplicationPolicyDestination struct {
+	// +optional
+	NamespaceSelector *metav1.LabelSelector `json:"namespaceSelector,omitempty"`
+	// +optional
+	Namespaces []string `json:"namespaces,omitempty"`
+}
+
+// TransformType names a kind of transform of ReplicatedResourceTransform.
+// +kubebuilder:validation:Enum=Keys;Template
+type TransformType string
+
+const (
+	// TransformKeys is the transform.keys transform.
+	TransformKeys TransformType = "Keys"
+	// TransformTemplate is the transform.template transform.
+	TransformTemplate TransformType = "Template"
+)
+
+// ReplicationPolicyRule allows the sources it selects to be replicated
+// to the destination namespaces it selects.
+type ReplicationPolicyRule struct {
+	Source ReplicationPolicySource `json:"source,omitempty"`
+	// +optional
+	Destination ReplicationPolicyDestination `json:"destination,omitempty"`
+	// AllowedTransforms lists the transforms that can be used, none can be
+	// used when empty.
+	// +optional
+	AllowedTransforms []TransformType `json:"allowedTransforms,omitempty"`
+	// MaxFanOut limits the number of ReplicatedResources replicating
+	// each source, the oldest ones are allowed. Each namespace an annotated
+	// source is pushed to counts after the ReplicatedResources.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxFanOut *int32 `json:"maxFanOut,omitempty"`
+}
+
+// ReplicationPolicySpec defines the desired state of ReplicationPolicy
+type ReplicationPolicySpec struct {
+	// Rules are evaluated for every source of a ReplicatedResource. A
+	// source selected by any rule of any policy must be allowed by one of
+	// the rules selecting it.
+	Rules []ReplicationPolicyRule `json:"rules,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:scope=Cluster
+
+// ReplicationPolicy is the Schema for the replicationpolicies API
+type ReplicationPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec ReplicationPolicySpec `json:"spec,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// ReplicationPolicyList contains a list of ReplicationPolicy
+type ReplicationPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []ReplicationPolicy `json:"items"`
+}
+
+func init() {
+	SchemeBuilder.Register(&ReplicationPolicy{}, &ReplicationPolicyList{})
+}
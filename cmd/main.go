@@ -19,6 +19,7 @@ package main
 import (
 	"flag"
 	"os"
+	"time"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -35,6 +36,7 @@ import (
 
 	utilsv1alpha1 "github.com/russell/resource-replication-operator/api/v1alpha1"
 	"github.com/russell/resource-replication-operator/internal/controller"
+	webhookv1alpha1 "github.com/russell/resource-replication-operator/internal/webhook/v1alpha1"
 	// +kubebuilder:scaffold:imports
 )
 
@@ -54,12 +56,28 @@ func main() {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var policyDefaultDeny bool
+	var accessReviewInterval time.Duration
+	var resyncInterval time.Duration
+	var compatibilityAnnotations bool
+	var rolloutInterval time.Duration
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metric endpoint binds to. "+
 		"Use :8080 for HTTP or :8443 for HTTPS, or leave as 0 to disable the metrics server")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.BoolVar(&policyDefaultDeny, "policy-default-deny", false,
+		"Deny replicating sources that aren't selected by any ReplicationPolicy rule.")
+	flag.DurationVar(&accessReviewInterval, "access-review-interval", 10*time.Minute,
+		"How often to check that the user who created a ReplicatedResource can still read its sources. "+
+			"Requires the admission webhook, which records the user.")
+	flag.DurationVar(&resyncInterval, "resync-interval", 30*time.Minute,
+		"How often sources are replicated again when nothing changed, keep it below the ReplicationStale alert threshold.")
+	flag.BoolVar(&compatibilityAnnotations, "compatibility-annotations", false,
+		"Honour the kubed.appscode.com/sync and reflector.v1.k8s.emberstack.com annotations of sources.")
+	flag.DurationVar(&rolloutInterval, "rollout-interval", 10*time.Second,
+		"The minimum time between two workloads restarted by a ReplicatedResource rollout.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -86,13 +104,49 @@ func main() {
 	}
 
 	if err = (&controller.ReplicatedResourceReconciler{
-		Client: mgr.GetClient(),
-		Log:    ctrl.Log.WithName("controllers").WithName("ReplicatedResource"),
-		Scheme: mgr.GetScheme(),
+		Client:                   mgr.GetClient(),
+		Log:                      ctrl.Log.WithName("controllers").WithName("ReplicatedResource"),
+		Scheme:                   mgr.GetScheme(),
+		Recorder:                 mgr.GetEventRecorderFor("replicatedresource-controller"),
+		PolicyDefaultDeny:        policyDefaultDeny,
+		AccessReviewInterval:     accessReviewInterval,
+		ResyncInterval:           resyncInterval,
+		CompatibilityAnnotations: compatibilityAnnotations,
+		RolloutInterval:          rolloutInterval,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "ReplicatedResource")
 		os.Exit(1)
 	}
+	if err = (&controller.ClusterReplicatedResourceReconciler{
+		Client:                   mgr.GetClient(),
+		Log:                      ctrl.Log.WithName("controllers").WithName("ClusterReplicatedResource"),
+		Scheme:                   mgr.GetScheme(),
+		Recorder:                 mgr.GetEventRecorderFor("clusterreplicatedresource-controller"),
+		ResyncInterval:           resyncInterval,
+		CompatibilityAnnotations: compatibilityAnnotations,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "ClusterReplicatedResource")
+		os.Exit(1)
+	}
+	if err = (&controller.AnnotatedSourceReconciler{
+		Client:                   mgr.GetClient(),
+		Log:                      ctrl.Log.WithName("controllers").WithName("AnnotatedSource"),
+		Scheme:                   mgr.GetScheme(),
+		Recorder:                 mgr.GetEventRecorderFor("annotatedsource-controller"),
+		PolicyDefaultDeny:        policyDefaultDeny,
+		ResyncInterval:           resyncInterval,
+		CompatibilityAnnotations: compatibilityAnnotations,
+	}).SetupWithManager(mgr); err != nil {
+		setupLog.Error(err, "unable to create controller", "controller", "AnnotatedSource")
+		os.Exit(1)
+	}
+	// nolint:goconst
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = webhookv1alpha1.SetupReplicatedResourceWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "ReplicatedResource")
+			os.Exit(1)
+		}
+	}
 	// +kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("health", healthz.Ping); err != nil {
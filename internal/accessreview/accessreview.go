@@ -0,0 +1,118 @@
+/*
+Copyright 2021-2022 Russell Sim <russell.sim@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package accessreview checks that the user that created a
+// ReplicatedResource can read its sources, with SubjectAccessReviews.
+package accessreview
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	utilsv1alpha1 "github.com/russell/resource-replication-operator/api/v1alpha1"
+	"github.com/russell/resource-replication-operator/replicator"
+	"github.com/russell/resource-replication-operator/replicator/common"
+)
+
+// User is the user whose access is reviewed.
+type User struct {
+	Name   string
+	Groups []string
+}
+
+// RecordedUser returns the user recorded on obj by Record, ok is false
+// when there is none.
+func RecordedUser(obj metav1.Object) (user User, ok bool) {
+	user.Name, ok = obj.GetAnnotations()[common.ReviewedUserAnnotation]
+	if groups := obj.GetAnnotations()[common.ReviewedGroupsAnnotation]; groups != "" {
+		user.Groups = strings.Split(groups, ",")
+	}
+	return user, ok
+}
+
+// Record records user on obj.
+func Record(obj metav1.Object, user User) {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	annotations[common.ReviewedUserAnnotation] = user.Name
+	annotations[common.ReviewedGroupsAnnotation] = strings.Join(user.Groups, ",")
+	obj.SetAnnotations(annotations)
+}
+
+// Review returns an Unauthorized replication error unless user can get
+// every source of rr, or the kubeconfig Secret of sources in other
+// clusters.
+func Review(ctx context.Context, c client.Client, mapper meta.RESTMapper, user User, rr *utilsv1alpha1.ReplicatedResource) error {
+	for _, source := range rr.Spec.AllSources() {
+		attributes, err := sourceAttributes(mapper, rr, source)
+		if err != nil {
+			return err
+		}
+		review := &authorizationv1.SubjectAccessReview{
+			Spec: authorizationv1.SubjectAccessReviewSpec{
+				User:               user.Name,
+				Groups:             user.Groups,
+				ResourceAttributes: attributes,
+			},
+		}
+		if err := c.Create(ctx, review); err != nil {
+			return err
+		}
+		if !review.Status.Allowed {
+			return &replicator.Error{
+				Reason:  "Unauthorized",
+				Message: fmt.Sprintf("User %s can't get %s %s/%s", user.Name, attributes.Resource, attributes.Namespace, attributes.Name),
+			}
+		}
+	}
+	return nil
+}
+
+// sourceAttributes returns the attributes of getting source, or its
+// kubeconfig Secret if it's in another cluster.
+func sourceAttributes(mapper meta.RESTMapper, rr *utilsv1alpha1.ReplicatedResource, source utilsv1alpha1.ReplicatedResourceSource) (*authorizationv1.ResourceAttributes, error) {
+	if source.ClusterRef != nil {
+		return &authorizationv1.ResourceAttributes{
+			Namespace: rr.Namespace,
+			Verb:      "get",
+			Version:   "v1",
+			Resource:  "secrets",
+			Name:      source.ClusterRef.Name,
+		}, nil
+	}
+
+	gvk := source.GroupVersionKind()
+	mapping, err := mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return nil, err
+	}
+	return &authorizationv1.ResourceAttributes{
+		Namespace: source.Namespace,
+		Verb:      "get",
+		Group:     gvk.Group,
+		Version:   gvk.Version,
+		Resource:  mapping.Resource.Resource,
+		Name:      source.Name,
+	}, nil
+}
@@ -0,0 +1,579 @@
+/*
+Copyright 2021-2022 Russell Sim <russell.sim@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	utilsv1alpha1 "github.com/russell/resource-replication-operator/api/v1alpha1"
+	"github.com/russell/resource-replication-operator/replicator"
+	"github.com/russell/resource-replication-operator/replicator/common"
+)
+
+// annotatedKind is a kind that can be pushed to other namespaces with
+// common.ReplicateToAnnotation.
+type annotatedKind struct {
+	gvk     schema.GroupVersionKind
+	newList func() client.ObjectList
+}
+
+// annotatedKinds are the kinds watched by the AnnotatedSourceReconciler.
+var annotatedKinds = []annotatedKind{
+	{gvk: corev1.SchemeGroupVersion.WithKind("ConfigMap"), newList: func() client.ObjectList { return &corev1.ConfigMapList{} }},
+	{gvk: corev1.SchemeGroupVersion.WithKind("Secret"), newList: func() client.ObjectList { return &corev1.SecretList{} }},
+}
+
+// pushStatus is the content of common.ReplicationStatusAnnotation.
+type pushStatus struct {
+	// Namespaces that may hold a copy of the source.
+	Namespaces []string `json:"namespaces,omitempty"`
+	// Failed maps the namespaces the source couldn't be copied to, or
+	// removed from, to the error.
+	Failed map[string]string `json:"failed,omitempty"`
+	// Error is set when the annotations of the source are invalid.
+	Error string `json:"error,omitempty"`
+}
+
+// AnnotatedSourceReconciler copies the Secrets and ConfigMaps annotated with
+// common.ReplicateToAnnotation or common.ReplicateToSelectorAnnotation to
+// the namespaces they select that accept them with
+// common.AcceptFromAnnotation. The copies are marked with the
+// common.RemoteOwnerAnnotation of their source and are deleted when their
+// namespace is no longer selected, but kept when the source is deleted
+// unless they were selected by kubed or Reflector annotations.
+// Every copy must be allowed by the ReplicationPolicies.
+type AnnotatedSourceReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+	// Replicators holds the replicators of Secrets and ConfigMaps,
+	// defaults to replicator.NewRegistry.
+	Replicators *replicator.Registry
+	// Recorder records events, defaults to the manager's recorder.
+	Recorder record.EventRecorder
+	// PolicyDefaultDeny denies copying sources that aren't selected by any
+	// ReplicationPolicy rule.
+	PolicyDefaultDeny bool
+	// ResyncInterval is how often the sources are copied again when
+	// nothing changes, defaults to 30 minutes.
+	ResyncInterval time.Duration
+	// CompatibilityAnnotations also copies the sources annotated with
+	// common.KubedSyncAnnotation or common.ReflectionAutoEnabledAnnotation.
+	CompatibilityAnnotations bool
+}
+
+// +kubebuilder:rbac:groups="",resources=secrets;configmaps,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=utils.simopolis.xyz,resources=replicationpolicies,verbs=get;list;watch
+
+// reconcileKind replicates the source of the annotated kind named by req,
+// kindReplicator being the replicator of the kind.
+func (r *AnnotatedSourceReconciler) reconcileKind(ctx context.Context, kindReplicator replicator.Replicator, annotated annotatedKind, req ctrl.Request) (ctrl.Result, error) {
+	kind := annotated.gvk.Kind
+	log := r.Log.WithValues(strings.ToLower(kind), req.NamespacedName)
+
+	source := kindReplicator.NewObject()
+	if err := r.Get(ctx, req.NamespacedName, source); err != nil {
+		if !kerrors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		}
+		// The copies are kept, as they would be by a ReplicatedResource,
+		// unless kubed or Reflector would have deleted them
+		log.Info(fmt.Sprintf("Could not find %s. Ignoring since object must be deleted.", kind))
+		forgetOwnerMetrics(kind, req.Namespace, req.Name)
+		if r.CompatibilityAnnotations {
+			return ctrl.Result{}, r.deleteCopies(ctx, log, annotated, fmt.Sprintf("%s/%s", kind, req.NamespacedName))
+		}
+		return ctrl.Result{}, nil
+	}
+	log.Info("Started Processing")
+
+	// The copies are found by their owner, the status annotation of the
+	// source can be edited by anyone who can edit the source
+	copies, err := r.copies(ctx, annotated, replicator.RemoteOwner(kind, source))
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	status := pushStatus{}
+	var replicateErrors []error
+	selected, refused, err := r.pushNamespaces(ctx, source)
+	if err != nil {
+		var replicationErr *replicator.Error
+		if !errors.As(err, &replicationErr) {
+			return ctrl.Result{}, err
+		}
+		status.Error = err.Error()
+		replicateErrors = append(replicateErrors, err)
+	}
+
+	if status.Error == "" {
+		var errs []error
+		status.Namespaces, status.Failed, errs = r.replicate(ctx, log, kindReplicator, kind, source, selected, copies)
+		replicateErrors = append(replicateErrors, errs...)
+		for _, namespace := range refused {
+			err := &replicator.Error{
+				Reason:  "NotAccepted",
+				Message: fmt.Sprintf("Namespace %s doesn't accept copies from namespace %s in %s", namespace, source.GetNamespace(), common.AcceptFromAnnotation),
+			}
+			if status.Failed == nil {
+				status.Failed = make(map[string]string)
+			}
+			status.Failed[namespace] = err.Error()
+			replicateErrors = append(replicateErrors, fmt.Errorf("namespace %s: %w", namespace, err))
+			recordReplicationMetrics(kind, "", replicator.Result{}, err)
+		}
+	} else {
+		status.Namespaces = copyNamespaces(copies)
+	}
+
+	if err := r.updateStatus(ctx, source, status); err != nil {
+		return ctrl.Result{}, err
+	}
+	recordOwnerMetrics(kind, source, len(status.Namespaces), len(replicateErrors) == 0)
+
+	// Errors that aren't replication errors, such as API errors, are retried
+	for _, err := range replicateErrors {
+		if replicator.ReasonFor(err) == "Error" {
+			return ctrl.Result{}, utilerrors.NewAggregate(replicateErrors)
+		}
+	}
+	return ctrl.Result{RequeueAfter: r.ResyncInterval}, nil
+}
+
+// replicate copies source to the selected namespaces that the
+// ReplicationPolicies allow, and removes the existing copies from the
+// namespaces that are no longer selected or allowed. It returns the sorted
+// namespaces that may still hold a copy, the failures by namespace and the
+// errors.
+func (r *AnnotatedSourceReconciler) replicate(ctx context.Context, log logr.Logger, kindReplicator replicator.Replicator, kind string, source client.Object, selected []string, copies []client.Object) ([]string, map[string]string, []error) {
+	var replicateErrors []error
+	failed := make(map[string]string)
+	sourceNamespacedName := client.ObjectKeyFromObject(source)
+	owner := replicator.RemoteOwner(kind, source)
+	previous := copyNamespaces(copies)
+	var annotations map[string]string
+	if r.CompatibilityAnnotations && foreignPushes(source) {
+		annotations = map[string]string{common.DeleteWithSourceAnnotation: "true"}
+	}
+
+	policies := policyChecker{Reader: r.Client, defaultDeny: r.PolicyDefaultDeny}
+	policySource := utilsv1alpha1.ReplicatedResourceSource{
+		Namespace: source.GetNamespace(),
+		Name:      source.GetName(),
+		Kind:      kind,
+	}
+
+	namespaces := []string{}
+	var allowed []string
+	for i, namespace := range selected {
+		// Each copy counts towards MaxFanOut after the ReplicatedResources
+		err := policies.check(ctx, []utilsv1alpha1.ReplicatedResourceSource{policySource}, policyReplication{
+			destNamespace: namespace,
+			fanOutRank: func(replicating []utilsv1alpha1.ReplicatedResource) int {
+				return len(replicating) + i
+			},
+		})
+		if err != nil {
+			failed[namespace] = err.Error()
+			replicateErrors = append(replicateErrors, fmt.Errorf("namespace %s: %w", namespace, err))
+			var replicationErr *replicator.Error
+			if errors.As(err, &replicationErr) {
+				// A denied copy is removed below
+				log.Info(fmt.Sprintf("Not copying to namespace %s: %s", namespace, err))
+				recordReplicationMetrics(kind, "", replicator.Result{}, err)
+				continue
+			}
+			// The policies couldn't be read, an existing copy is kept
+			allowed = append(allowed, namespace)
+			if slices.Contains(previous, namespace) {
+				namespaces = append(namespaces, namespace)
+			}
+			continue
+		}
+		allowed = append(allowed, namespace)
+
+		destNamespacedName := types.NamespacedName{Namespace: namespace, Name: source.GetName()}
+		replication := &replicator.Replication{
+			Source:      sourceNamespacedName,
+			Destination: destNamespacedName,
+			Annotations: annotations,
+			RemoteOwner: owner,
+			// The annotation is the consent of the source
+			SourceConsented: true,
+		}
+		result, err := replicator.ReplicateFrom(ctx, r.Client, log, kindReplicator, replication, source)
+		if !result.Conflict {
+			namespaces = append(namespaces, namespace)
+		}
+		recordReplication(r.Recorder, source, destNamespacedName.String(), replication.DriftPolicy, result, err)
+		recordReplicationMetrics(kind, replication.DriftPolicy, result, err)
+		if err != nil {
+			failed[namespace] = err.Error()
+			replicateErrors = append(replicateErrors, fmt.Errorf("namespace %s: %w", namespace, err))
+		}
+	}
+
+	for _, dest := range copies {
+		namespace := dest.GetNamespace()
+		if slices.Contains(allowed, namespace) {
+			continue
+		}
+		log.Info(fmt.Sprintf("Namespace %s is no longer selected or allowed, removing copy", namespace))
+		if err := r.Delete(ctx, dest); client.IgnoreNotFound(err) != nil {
+			if _, denied := failed[namespace]; !denied {
+				failed[namespace] = err.Error()
+			}
+			replicateErrors = append(replicateErrors, fmt.Errorf("namespace %s: %w", namespace, err))
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	sort.Strings(namespaces)
+
+	if len(failed) == 0 {
+		failed = nil
+	}
+	return namespaces, failed, replicateErrors
+}
+
+// pushNamespaces returns the sorted names of the namespaces selected by the
+// annotations of source, other than its own namespace, that accept its
+// copies, and the names of the namespaces listed by
+// common.ReplicateToAnnotation that don't. Invalid annotations, including
+// an empty common.ReplicateToSelectorAnnotation that would select every
+// namespace, are reported as an InvalidAnnotation replicator.Error.
+func (r *AnnotatedSourceReconciler) pushNamespaces(ctx context.Context, source client.Object) ([]string, []string, error) {
+	if !r.pushes(source) {
+		return nil, nil, nil
+	}
+	annotations := source.GetAnnotations()
+	var names []string
+	for _, name := range strings.Split(annotations[common.ReplicateToAnnotation], ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	selector := labels.Nothing()
+	if annotation, ok := annotations[common.ReplicateToSelectorAnnotation]; ok {
+		if strings.TrimSpace(annotation) == "" {
+			return nil, nil, &replicator.Error{
+				Reason:  "InvalidAnnotation",
+				Message: fmt.Sprintf("Empty namespace selector in %s, it would select every namespace", common.ReplicateToSelectorAnnotation),
+			}
+		}
+		s, err := labels.Parse(annotation)
+		if err != nil {
+			return nil, nil, &replicator.Error{
+				Reason:  "InvalidAnnotation",
+				Message: fmt.Sprintf("Invalid namespace selector in %s: %s", common.ReplicateToSelectorAnnotation, err),
+			}
+		}
+		selector = s
+	}
+	kubedSelector := labels.Nothing()
+	if annotation, ok := annotations[common.KubedSyncAnnotation]; ok && r.CompatibilityAnnotations {
+		// An empty selector syncs to every namespace
+		s, err := labels.Parse(annotation)
+		if err != nil {
+			return nil, nil, &replicator.Error{
+				Reason:  "InvalidAnnotation",
+				Message: fmt.Sprintf("Invalid namespace selector in %s: %s", common.KubedSyncAnnotation, err),
+			}
+		}
+		kubedSelector = s
+	}
+
+	namespaceList := &corev1.NamespaceList{}
+	if err := r.List(ctx, namespaceList); err != nil {
+		return nil, nil, err
+	}
+
+	var namespaces, refused []string
+	for _, namespace := range namespaceList.Items {
+		if namespace.Status.Phase == corev1.NamespaceTerminating || namespace.Name == source.GetNamespace() {
+			continue
+		}
+		named := slices.Contains(names, namespace.Name)
+		selected := named || selector.Matches(labels.Set(namespace.Labels)) || kubedSelector.Matches(labels.Set(namespace.Labels))
+		if !selected && r.CompatibilityAnnotations {
+			auto, err := replicator.ReflectionAuto(source, namespace.Name)
+			if err != nil {
+				return nil, nil, &replicator.Error{Reason: "InvalidAnnotation", Message: err.Error()}
+			}
+			selected = auto
+		}
+		if !selected {
+			continue
+		}
+		// Only the namespaces named by the source report their refusal,
+		// a selector may match any namespace
+		if !acceptsFrom(&namespace, source.GetNamespace()) {
+			if named {
+				refused = append(refused, namespace.Name)
+			}
+			continue
+		}
+		namespaces = append(namespaces, namespace.Name)
+	}
+	sort.Strings(namespaces)
+	sort.Strings(refused)
+	return namespaces, refused, nil
+}
+
+// acceptsFrom reports whether namespace accepts the copies of the sources
+// in the source namespace, by its common.AcceptFromAnnotation.
+func acceptsFrom(namespace *corev1.Namespace, source string) bool {
+	for _, accepted := range strings.Split(namespace.Annotations[common.AcceptFromAnnotation], ",") {
+		if accepted = strings.TrimSpace(accepted); accepted == "*" || accepted == source {
+			return true
+		}
+	}
+	return false
+}
+
+// copies returns the copies of the annotated kind marked with owner, the
+// common.RemoteOwnerAnnotation of their source.
+func (r *AnnotatedSourceReconciler) copies(ctx context.Context, annotated annotatedKind, owner string) ([]client.Object, error) {
+	list := annotated.newList()
+	if err := r.List(ctx, list); err != nil {
+		return nil, err
+	}
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return nil, err
+	}
+	var copies []client.Object
+	for _, item := range items {
+		if dest, ok := item.(client.Object); ok && dest.GetAnnotations()[common.RemoteOwnerAnnotation] == owner {
+			copies = append(copies, dest)
+		}
+	}
+	return copies, nil
+}
+
+// copyNamespaces returns the sorted namespaces of copies.
+func copyNamespaces(copies []client.Object) []string {
+	var namespaces []string
+	for _, dest := range copies {
+		namespaces = append(namespaces, dest.GetNamespace())
+	}
+	sort.Strings(namespaces)
+	return namespaces
+}
+
+// deleteCopies deletes the copies of the annotated kind marked with owner,
+// the common.RemoteOwnerAnnotation of their deleted source, and with
+// common.DeleteWithSourceAnnotation.
+func (r *AnnotatedSourceReconciler) deleteCopies(ctx context.Context, log logr.Logger, annotated annotatedKind, owner string) error {
+	copies, err := r.copies(ctx, annotated, owner)
+	if err != nil {
+		return err
+	}
+	for _, dest := range copies {
+		if dest.GetAnnotations()[common.DeleteWithSourceAnnotation] != "true" {
+			continue
+		}
+		log.Info(fmt.Sprintf("Source was deleted, removing copy in namespace %s", dest.GetNamespace()))
+		if err := r.Delete(ctx, dest); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateStatus patches the common.ReplicationStatusAnnotation of source to
+// status if it changed. The annotation is removed once the source no
+// longer asks to be replicated and has no copies left.
+func (r *AnnotatedSourceReconciler) updateStatus(ctx context.Context, source client.Object, status pushStatus) error {
+	annotations := maps.Clone(source.GetAnnotations())
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	if r.pushes(source) || len(status.Namespaces) > 0 || len(status.Failed) > 0 {
+		data, err := json.Marshal(status)
+		if err != nil {
+			return err
+		}
+		annotations[common.ReplicationStatusAnnotation] = string(data)
+	} else {
+		delete(annotations, common.ReplicationStatusAnnotation)
+	}
+	if annotations[common.ReplicationStatusAnnotation] == source.GetAnnotations()[common.ReplicationStatusAnnotation] {
+		return nil
+	}
+
+	patch := client.MergeFrom(source.DeepCopyObject().(client.Object))
+	source.SetAnnotations(annotations)
+	return r.Patch(ctx, source, patch)
+}
+
+// pushes reports whether obj asks to be replicated to other namespaces.
+func (r *AnnotatedSourceReconciler) pushes(obj client.Object) bool {
+	annotations := obj.GetAnnotations()
+	_, to := annotations[common.ReplicateToAnnotation]
+	_, selector := annotations[common.ReplicateToSelectorAnnotation]
+	return to || selector || (r.CompatibilityAnnotations && foreignPushes(obj))
+}
+
+// foreignPushes reports whether obj asks kubed or Reflector to replicate it
+// to other namespaces.
+func foreignPushes(obj client.Object) bool {
+	annotations := obj.GetAnnotations()
+	_, kubed := annotations[common.KubedSyncAnnotation]
+	reflector := strings.EqualFold(annotations[common.ReflectionAllowedAnnotation], "true") &&
+		strings.EqualFold(annotations[common.ReflectionAutoEnabledAnnotation], "true")
+	return kubed || reflector
+}
+
+// sourcePredicate passes the events of objects that ask to be replicated or
+// still record copies. Deletions are ignored as the copies are kept, unless
+// kubed or Reflector would have deleted them.
+func (r *AnnotatedSourceReconciler) sourcePredicate() predicate.Funcs {
+	return predicate.Funcs{
+		CreateFunc: func(e event.CreateEvent) bool {
+			return r.pushes(e.Object) || hasPushStatus(e.Object)
+		},
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			if !r.pushes(e.ObjectOld) && !r.pushes(e.ObjectNew) && !hasPushStatus(e.ObjectNew) {
+				return false
+			}
+			return predicate.ResourceVersionChangedPredicate{}.Update(e)
+		},
+		DeleteFunc: func(e event.DeleteEvent) bool {
+			return r.CompatibilityAnnotations && foreignPushes(e.Object)
+		},
+		GenericFunc: func(event.GenericEvent) bool { return false },
+	}
+}
+
+// hasPushStatus reports whether obj has a
+// common.ReplicationStatusAnnotation.
+func hasPushStatus(obj client.Object) bool {
+	_, ok := obj.GetAnnotations()[common.ReplicationStatusAnnotation]
+	return ok
+}
+
+// findSourceOfCopy returns a request for the source of a copy of kind, read
+// from its common.RemoteOwnerAnnotation.
+func findSourceOfCopy(kind string) handler.MapFunc {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		parts := strings.SplitN(obj.GetAnnotations()[common.RemoteOwnerAnnotation], "/", 3)
+		if len(parts) != 3 || parts[0] != kind {
+			return nil
+		}
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Namespace: parts[1], Name: parts[2]}}}
+	}
+}
+
+// findPushSources returns a request for every object of the kind listed by
+// newList that asks to be replicated or still records copies, when a
+// Namespace or a ReplicationPolicy changes.
+func (r *AnnotatedSourceReconciler) findPushSources(newList func() client.ObjectList) handler.MapFunc {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		list := newList()
+		if err := r.List(ctx, list); err != nil {
+			return []reconcile.Request{}
+		}
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			return []reconcile.Request{}
+		}
+
+		var requests []reconcile.Request
+		for _, item := range items {
+			if source, ok := item.(client.Object); ok && (r.pushes(source) || hasPushStatus(source)) {
+				requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(source)})
+			}
+		}
+		return requests
+	}
+}
+
+// SetupWithManager sets up a controller for every annotated kind with the
+// Manager.
+func (r *AnnotatedSourceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if r.Replicators == nil {
+		r.Replicators = replicator.NewRegistry(r.Log)
+	}
+	if r.Recorder == nil {
+		r.Recorder = mgr.GetEventRecorderFor("annotatedsource-controller")
+	}
+	if r.ResyncInterval == 0 {
+		r.ResyncInterval = defaultResyncInterval
+	}
+
+	for _, annotated := range annotatedKinds {
+		kindReplicator, ok := r.Replicators.Get(annotated.gvk)
+		if !ok {
+			return fmt.Errorf("No replicator registered for %s", annotated.gvk)
+		}
+		kind := annotated.gvk.Kind
+		err := ctrl.NewControllerManagedBy(mgr).
+			Named("Annotated"+kind).
+			For(kindReplicator.NewObject(), builder.WithPredicates(r.sourcePredicate())).
+			Watches(
+				kindReplicator.NewObject(),
+				handler.EnqueueRequestsFromMapFunc(findSourceOfCopy(kind)),
+				builder.WithPredicates(predicate.NewPredicateFuncs(func(obj client.Object) bool {
+					return strings.HasPrefix(obj.GetAnnotations()[common.RemoteOwnerAnnotation], kind+"/")
+				})),
+			).
+			Watches(
+				&corev1.Namespace{},
+				handler.EnqueueRequestsFromMapFunc(r.findPushSources(annotated.newList)),
+				builder.WithPredicates(predicate.Or(predicate.LabelChangedPredicate{}, predicate.AnnotationChangedPredicate{})),
+			).
+			Watches(
+				&utilsv1alpha1.ReplicationPolicy{},
+				handler.EnqueueRequestsFromMapFunc(r.findPushSources(annotated.newList)),
+			).
+			Complete(reconcile.Func(func(ctx context.Context, req reconcile.Request) (reconcile.Result, error) {
+				return r.reconcileKind(ctx, kindReplicator, annotated, req)
+			}))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
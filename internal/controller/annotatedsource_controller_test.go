@@ -0,0 +1,569 @@
+/*
+Copyright 2021-2022 Russell Sim <russell.sim@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	utilsv1alpha1 "github.com/russell/resource-replication-operator/api/v1alpha1"
+	"github.com/russell/resource-replication-operator/replicator"
+	"github.com/russell/resource-replication-operator/replicator/common"
+)
+
+var _ = Describe("Annotated source controller", func() {
+
+	const (
+		SourceNamespace = "default"
+
+		timeout  = time.Second * 10
+		interval = time.Millisecond * 250
+	)
+
+	// createNamespace creates a namespace that accepts copies from
+	// SourceNamespace
+	createNamespace := func(ctx context.Context, name string, labels map[string]string) {
+		namespace := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        name,
+				Labels:      labels,
+				Annotations: map[string]string{common.AcceptFromAnnotation: SourceNamespace},
+			},
+		}
+		Expect(k8sClient.Create(ctx, namespace)).Should(Succeed())
+	}
+
+	getStatus := func(ctx context.Context, obj client.Object) func() pushStatus {
+		return func() pushStatus {
+			status := pushStatus{}
+			if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+				return status
+			}
+			_ = json.Unmarshal([]byte(obj.GetAnnotations()[common.ReplicationStatusAnnotation]), &status)
+			return status
+		}
+	}
+
+	Context("When annotating a Secret", func() {
+		It("Should push it to the listed and selected namespaces", func() {
+			ctx := context.Background()
+
+			By("By creating namespaces")
+			createNamespace(ctx, "push-listed", nil)
+			createNamespace(ctx, "push-selected", map[string]string{"push": "true"})
+			createNamespace(ctx, "push-other", nil)
+
+			By("By creating an annotated Secret")
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "pushed-secret",
+					Namespace: SourceNamespace,
+					Annotations: map[string]string{
+						common.ReplicateToAnnotation:         "push-listed, missing",
+						common.ReplicateToSelectorAnnotation: "push=true",
+					},
+				},
+				Data: map[string][]byte{
+					"password": []byte("hunter2"),
+				},
+			}
+			Expect(k8sClient.Create(ctx, secret)).Should(Succeed())
+
+			getCopy := func(namespace string) func() error {
+				return func() error {
+					return k8sClient.Get(ctx, types.NamespacedName{Name: secret.Name, Namespace: namespace}, &corev1.Secret{})
+				}
+			}
+
+			Eventually(getCopy("push-listed"), timeout, interval).Should(Succeed())
+			Eventually(getCopy("push-selected"), timeout, interval).Should(Succeed())
+			Consistently(getCopy("push-other"), time.Second, interval).ShouldNot(Succeed())
+
+			copied := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: secret.Name, Namespace: "push-listed"}, copied)).Should(Succeed())
+			Expect(copied.Data).Should(Equal(secret.Data))
+			Expect(copied.Annotations).Should(HaveKeyWithValue(common.RemoteOwnerAnnotation, replicator.RemoteOwner("Secret", secret)))
+			Expect(copied.Annotations).ShouldNot(HaveKey(common.ReplicateToAnnotation))
+
+			Eventually(func() string {
+				if err := k8sClient.Get(ctx, types.NamespacedName{Name: secret.Name, Namespace: SourceNamespace}, secret); err != nil {
+					return ""
+				}
+				return secret.Annotations[common.ReplicationStatusAnnotation]
+			}, timeout, interval).Should(MatchJSON(`{"namespaces":["push-listed","push-selected"]}`))
+
+			By("By creating a listed namespace")
+			createNamespace(ctx, "missing", nil)
+			Eventually(getCopy("missing"), timeout, interval).Should(Succeed())
+
+			By("By removing a namespace from the annotation")
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: secret.Name, Namespace: SourceNamespace}, secret)).Should(Succeed())
+			secret.Annotations[common.ReplicateToAnnotation] = "missing"
+			Expect(k8sClient.Update(ctx, secret)).Should(Succeed())
+			Eventually(func() bool {
+				return errors.IsNotFound(getCopy("push-listed")())
+			}, timeout, interval).Should(BeTrue())
+			Expect(getCopy("push-selected")()).Should(Succeed())
+		})
+	})
+
+	Context("When annotating a ConfigMap", func() {
+		It("Should report conflicts and remove the copies once the annotation is removed", func() {
+			ctx := context.Background()
+
+			By("By creating namespaces")
+			createNamespace(ctx, "push-config", nil)
+			createNamespace(ctx, "push-conflict", nil)
+
+			By("By creating an unmanaged ConfigMap in the way")
+			existing := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "pushed-config",
+					Namespace: "push-conflict",
+				},
+				Data: map[string]string{"owner": "someone else"},
+			}
+			Expect(k8sClient.Create(ctx, existing)).Should(Succeed())
+
+			By("By creating an annotated ConfigMap")
+			configMap := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "pushed-config",
+					Namespace: SourceNamespace,
+					Annotations: map[string]string{
+						common.ReplicateToAnnotation: "push-config,push-conflict",
+					},
+				},
+				Data: map[string]string{"setting": "on"},
+			}
+			Expect(k8sClient.Create(ctx, configMap)).Should(Succeed())
+
+			Eventually(getStatus(ctx, configMap), timeout, interval).Should(And(
+				HaveField("Namespaces", Equal([]string{"push-config"})),
+				HaveField("Failed", HaveKey("push-conflict")),
+			))
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: existing.Name, Namespace: existing.Namespace}, existing)).Should(Succeed())
+			Expect(existing.Data).Should(Equal(map[string]string{"owner": "someone else"}))
+
+			By("By removing the annotation")
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: configMap.Name, Namespace: SourceNamespace}, configMap)).Should(Succeed())
+			delete(configMap.Annotations, common.ReplicateToAnnotation)
+			Expect(k8sClient.Update(ctx, configMap)).Should(Succeed())
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: configMap.Name, Namespace: "push-config"}, &corev1.ConfigMap{})
+				return errors.IsNotFound(err)
+			}, timeout, interval).Should(BeTrue())
+			Eventually(func() map[string]string {
+				if err := k8sClient.Get(ctx, types.NamespacedName{Name: configMap.Name, Namespace: SourceNamespace}, configMap); err != nil {
+					return nil
+				}
+				return configMap.Annotations
+			}, timeout, interval).ShouldNot(HaveKey(common.ReplicationStatusAnnotation))
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: existing.Name, Namespace: existing.Namespace}, existing)).Should(Succeed())
+		})
+	})
+
+	Context("When a namespace doesn't accept copies", func() {
+		It("Should only push to it once it accepts them", func() {
+			ctx := context.Background()
+
+			By("By creating namespaces without accept-from")
+			refusing := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "push-refusing"}}
+			Expect(k8sClient.Create(ctx, refusing)).Should(Succeed())
+			selected := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+				Name:   "push-refusing-selected",
+				Labels: map[string]string{"refuse": "true"},
+			}}
+			Expect(k8sClient.Create(ctx, selected)).Should(Succeed())
+
+			By("By creating an annotated Secret")
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "refused-secret",
+					Namespace: SourceNamespace,
+					Annotations: map[string]string{
+						common.ReplicateToAnnotation:         "push-refusing",
+						common.ReplicateToSelectorAnnotation: "refuse=true",
+					},
+				},
+				Data: map[string][]byte{"token": []byte("secret")},
+			}
+			Expect(k8sClient.Create(ctx, secret)).Should(Succeed())
+
+			getCopy := func(namespace string) func() error {
+				return func() error {
+					return k8sClient.Get(ctx, types.NamespacedName{Name: secret.Name, Namespace: namespace}, &corev1.Secret{})
+				}
+			}
+
+			Eventually(getStatus(ctx, secret), timeout, interval).Should(
+				HaveField("Failed", And(
+					HaveKeyWithValue("push-refusing", ContainSubstring("doesn't accept copies")),
+					Not(HaveKey("push-refusing-selected")),
+				)),
+			)
+			Consistently(getCopy("push-refusing"), time.Second, interval).ShouldNot(Succeed())
+			Expect(getCopy("push-refusing-selected")()).ShouldNot(Succeed())
+
+			By("By accepting copies from the source namespace")
+			Eventually(func() error {
+				if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(refusing), refusing); err != nil {
+					return err
+				}
+				refusing.Annotations = map[string]string{common.AcceptFromAnnotation: "other, " + SourceNamespace}
+				return k8sClient.Update(ctx, refusing)
+			}, timeout, interval).Should(Succeed())
+			Eventually(getCopy("push-refusing"), timeout, interval).Should(Succeed())
+			Eventually(getStatus(ctx, secret), timeout, interval).Should(And(
+				HaveField("Namespaces", Equal([]string{"push-refusing"})),
+				HaveField("Failed", BeEmpty()),
+			))
+
+			By("By no longer accepting copies")
+			Eventually(func() error {
+				if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(refusing), refusing); err != nil {
+					return err
+				}
+				refusing.Annotations = nil
+				return k8sClient.Update(ctx, refusing)
+			}, timeout, interval).Should(Succeed())
+			Eventually(func() bool {
+				return errors.IsNotFound(getCopy("push-refusing")())
+			}, timeout, interval).Should(BeTrue())
+		})
+	})
+
+	Context("When the replication status of a source is edited", func() {
+		It("Should still find its copies by their owner", func() {
+			ctx := context.Background()
+
+			By("By creating a namespace")
+			createNamespace(ctx, "push-owned", nil)
+
+			unrelated := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "pushed-owned",
+					Namespace: "push-owned",
+				},
+				Data: map[string][]byte{"owner": []byte("someone else")},
+			}
+
+			By("By creating an annotated Secret")
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "pushed-owned",
+					Namespace: SourceNamespace,
+					Annotations: map[string]string{
+						common.ReplicateToAnnotation: "push-owned",
+					},
+				},
+				Data: map[string][]byte{"token": []byte("secret")},
+			}
+			Expect(k8sClient.Create(ctx, secret)).Should(Succeed())
+			Eventually(getStatus(ctx, secret), timeout, interval).Should(
+				HaveField("Namespaces", Equal([]string{"push-owned"})),
+			)
+
+			By("By clearing the status and the annotation together")
+			Eventually(func() error {
+				if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(secret), secret); err != nil {
+					return err
+				}
+				secret.Annotations[common.ReplicationStatusAnnotation] = "{}"
+				delete(secret.Annotations, common.ReplicateToAnnotation)
+				return k8sClient.Update(ctx, secret)
+			}, timeout, interval).Should(Succeed())
+			Eventually(func() bool {
+				err := k8sClient.Get(ctx, client.ObjectKeyFromObject(unrelated), &corev1.Secret{})
+				return errors.IsNotFound(err)
+			}, timeout, interval).Should(BeTrue())
+
+			By("By creating an unrelated Secret with the same name")
+			Expect(k8sClient.Create(ctx, unrelated)).Should(Succeed())
+			Eventually(func() map[string]string {
+				if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(secret), secret); err != nil {
+					return nil
+				}
+				return secret.Annotations
+			}, timeout, interval).ShouldNot(HaveKey(common.ReplicationStatusAnnotation))
+			Consistently(func() error {
+				return k8sClient.Get(ctx, client.ObjectKeyFromObject(unrelated), &corev1.Secret{})
+			}, time.Second, interval).Should(Succeed())
+		})
+	})
+
+	Context("When a ReplicationPolicy denies an annotated source", func() {
+		It("Should refuse to push it", func() {
+			ctx := context.Background()
+			denials := testutil.ToFloat64(policyDenials.WithLabelValues("PolicyDenied"))
+
+			By("By creating a namespace")
+			createNamespace(ctx, "push-denied", nil)
+
+			By("By creating a policy that only allows another namespace")
+			policy := &utilsv1alpha1.ReplicationPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-annotated-denied"},
+				Spec: utilsv1alpha1.ReplicationPolicySpec{
+					Rules: []utilsv1alpha1.ReplicationPolicyRule{{
+						Source: utilsv1alpha1.ReplicationPolicySource{
+							Namespaces: []string{SourceNamespace},
+							Names:      []string{"denied-*"},
+							Kinds:      []string{"Secret"},
+						},
+						Destination: utilsv1alpha1.ReplicationPolicyDestination{
+							Namespaces: []string{"payments"},
+						},
+					}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, policy)).Should(Succeed())
+			defer func() {
+				Expect(k8sClient.Delete(ctx, policy)).Should(Succeed())
+			}()
+
+			By("By creating an annotated Secret")
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "denied-secret",
+					Namespace: SourceNamespace,
+					Annotations: map[string]string{
+						common.ReplicateToAnnotation: "push-denied",
+					},
+				},
+				Data: map[string][]byte{"token": []byte("secret")},
+			}
+			Expect(k8sClient.Create(ctx, secret)).Should(Succeed())
+
+			Eventually(getStatus(ctx, secret), timeout, interval).Should(
+				HaveField("Failed", HaveKeyWithValue("push-denied", ContainSubstring("ReplicationPolicy test-annotated-denied"))),
+			)
+			Expect(getStatus(ctx, secret)().Namespaces).Should(BeEmpty())
+			Expect(testutil.ToFloat64(policyDenials.WithLabelValues("PolicyDenied"))).Should(BeNumerically(">", denials))
+			Consistently(func() error {
+				return k8sClient.Get(ctx, types.NamespacedName{Name: secret.Name, Namespace: "push-denied"}, &corev1.Secret{})
+			}, time.Second, interval).ShouldNot(Succeed())
+
+			By("By selecting every namespace with an empty selector")
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: secret.Name, Namespace: SourceNamespace}, secret)).Should(Succeed())
+			secret.Annotations[common.ReplicateToSelectorAnnotation] = ""
+			Expect(k8sClient.Update(ctx, secret)).Should(Succeed())
+			Eventually(getStatus(ctx, secret), timeout, interval).Should(
+				HaveField("Error", ContainSubstring("Empty namespace selector")),
+			)
+		})
+
+		It("Should deny sources no policy selects with PolicyDefaultDeny", func() {
+			ctx := context.Background()
+			kindReplicator, ok := replicator.NewRegistry(GinkgoLogr).Get(corev1.SchemeGroupVersion.WithKind("Secret"))
+			Expect(ok).Should(BeTrue())
+
+			// The manager's reconciler allows them, this one isn't
+			// registered with the manager
+			r := &AnnotatedSourceReconciler{
+				Client:            k8sClient,
+				Log:               GinkgoLogr,
+				Recorder:          record.NewFakeRecorder(10),
+				PolicyDefaultDeny: true,
+			}
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "unselected-secret",
+					Namespace: SourceNamespace,
+				},
+			}
+			namespaces, failed, errs := r.replicate(ctx, GinkgoLogr, kindReplicator, "Secret", secret, []string{"push-default-deny"}, nil)
+			Expect(namespaces).Should(BeEmpty())
+			Expect(failed).Should(HaveKeyWithValue("push-default-deny", ContainSubstring("No ReplicationPolicy allows")))
+			Expect(errs).Should(HaveLen(1))
+		})
+	})
+
+	// The manager's reconciler ignores the kubed and Reflector annotations,
+	// these tests use their own reconciler that isn't registered with the
+	// manager, and sources that only exist in memory so that the manager
+	// leaves their copies alone.
+	Context("When honouring kubed and Reflector annotations", func() {
+		var r *AnnotatedSourceReconciler
+		BeforeEach(func() {
+			r = &AnnotatedSourceReconciler{
+				Client:                   k8sClient,
+				Log:                      GinkgoLogr,
+				Recorder:                 record.NewFakeRecorder(10),
+				CompatibilityAnnotations: true,
+			}
+		})
+
+		It("Should sync a Secret annotated with kubed.appscode.com/sync", func() {
+			ctx := context.Background()
+			secretKind := annotatedKind{
+				gvk:     corev1.SchemeGroupVersion.WithKind("Secret"),
+				newList: func() client.ObjectList { return &corev1.SecretList{} },
+			}
+			kindReplicator, ok := replicator.NewRegistry(GinkgoLogr).Get(secretKind.gvk)
+			Expect(ok).Should(BeTrue())
+
+			By("By creating namespaces")
+			createNamespace(ctx, "kubed-a", map[string]string{"app": "kubed"})
+			createNamespace(ctx, "kubed-b", nil)
+			refusing := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{
+				Name:   "kubed-refusing",
+				Labels: map[string]string{"app": "kubed"},
+			}}
+			Expect(k8sClient.Create(ctx, refusing)).Should(Succeed())
+
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "kubed-secret",
+					Namespace: SourceNamespace,
+					Annotations: map[string]string{
+						common.KubedSyncAnnotation: "app=kubed",
+					},
+				},
+				Data: map[string][]byte{"token": []byte("secret")},
+			}
+
+			By("By selecting the labelled namespaces that accept copies")
+			selected, refused, err := r.pushNamespaces(ctx, secret)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(selected).Should(Equal([]string{"kubed-a"}))
+			Expect(refused).Should(BeEmpty())
+
+			selected, _, err = (&AnnotatedSourceReconciler{Client: k8sClient}).pushNamespaces(ctx, secret)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(selected).Should(BeEmpty())
+
+			By("By syncing to every namespace")
+			secret.Annotations[common.KubedSyncAnnotation] = ""
+			selected, _, err = r.pushNamespaces(ctx, secret)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(selected).Should(ContainElements("kubed-a", "kubed-b"))
+			Expect(selected).ShouldNot(ContainElement("kubed-refusing"))
+			Expect(selected).ShouldNot(ContainElement(SourceNamespace))
+
+			By("By copying the Secret")
+			namespaces, failed, errs := r.replicate(ctx, GinkgoLogr, kindReplicator, "Secret", secret, []string{"kubed-a", "kubed-b"}, nil)
+			Expect(errs).Should(BeEmpty())
+			Expect(failed).Should(BeEmpty())
+			Expect(namespaces).Should(Equal([]string{"kubed-a", "kubed-b"}))
+			copied := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: secret.Name, Namespace: "kubed-a"}, copied)).Should(Succeed())
+			Expect(copied.Annotations).Should(HaveKeyWithValue(common.DeleteWithSourceAnnotation, "true"))
+			Expect(copied.Annotations).ShouldNot(HaveKey(common.KubedSyncAnnotation))
+
+			By("By deleting the copies of the deleted source")
+			Expect(r.deleteCopies(ctx, GinkgoLogr, secretKind, replicator.RemoteOwner("Secret", secret))).Should(Succeed())
+			Eventually(func() bool {
+				a := k8sClient.Get(ctx, types.NamespacedName{Name: secret.Name, Namespace: "kubed-a"}, &corev1.Secret{})
+				b := k8sClient.Get(ctx, types.NamespacedName{Name: secret.Name, Namespace: "kubed-b"}, &corev1.Secret{})
+				return errors.IsNotFound(a) && errors.IsNotFound(b)
+			}, timeout, interval).Should(BeTrue())
+		})
+
+		It("Should copy a ConfigMap to the reflection-auto-namespaces", func() {
+			ctx := context.Background()
+
+			By("By creating namespaces")
+			createNamespace(ctx, "reflector-auto", nil)
+			createNamespace(ctx, "reflector-allowed", nil)
+			createNamespace(ctx, "reflector-denied", nil)
+
+			configMap := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "reflected-config",
+					Namespace: SourceNamespace,
+					Annotations: map[string]string{
+						common.ReflectionAllowedAnnotation:           "true",
+						common.ReflectionAllowedNamespacesAnnotation: "reflector-a.*",
+						common.ReflectionAutoEnabledAnnotation:       "true",
+						common.ReflectionAutoNamespacesAnnotation:    "reflector-auto,reflector-denied",
+					},
+				},
+				Data: map[string]string{"setting": "on"},
+			}
+
+			selected, _, err := r.pushNamespaces(ctx, configMap)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(selected).Should(Equal([]string{"reflector-auto"}))
+
+			By("By disabling automatic reflection")
+			configMap.Annotations[common.ReflectionAutoEnabledAnnotation] = "false"
+			selected, _, err = r.pushNamespaces(ctx, configMap)
+			Expect(err).ShouldNot(HaveOccurred())
+			Expect(selected).Should(BeEmpty())
+		})
+
+		It("Should not let ReplicatedResources replicate a source with reflection-allowed by default", func() {
+			ctx := context.Background()
+
+			By("By creating a namespace")
+			createNamespace(ctx, "reflection-allowed", nil)
+
+			By("By creating a Secret annotated for Reflector")
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "reflection-allowed-secret",
+					Namespace: SourceNamespace,
+					Annotations: map[string]string{
+						common.ReflectionAllowedAnnotation:           "true",
+						common.ReflectionAllowedNamespacesAnnotation: "reflection-allowed",
+					},
+				},
+				Data: map[string][]byte{"token": []byte("secret")},
+			}
+			Expect(k8sClient.Create(ctx, secret)).Should(Succeed())
+
+			replicatedResource := &utilsv1alpha1.ReplicatedResource{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "reflected-secret",
+					Namespace: "reflection-allowed",
+				},
+				Spec: utilsv1alpha1.ReplicatedResourceSpec{
+					Source: utilsv1alpha1.ReplicatedResourceSource{
+						Namespace: SourceNamespace,
+						Name:      secret.Name,
+						Kind:      "Secret",
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, replicatedResource)).Should(Succeed())
+
+			Eventually(func() string {
+				if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(replicatedResource), replicatedResource); err != nil {
+					return ""
+				}
+				for _, condition := range replicatedResource.Status.Conditions {
+					if condition.Type == utilsv1alpha1.ReplicatedResourceAuthorized {
+						return condition.Reason
+					}
+				}
+				return ""
+			}, timeout, interval).Should(Equal("Forbidden"))
+		})
+	})
+})
@@ -0,0 +1,374 @@
+/*
+Copyright 2021-2022 Russell Sim <russell.sim@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	utilsv1alpha1 "github.com/russell/resource-replication-operator/api/v1alpha1"
+	"github.com/russell/resource-replication-operator/replicator"
+)
+
+// ClusterReplicatedResourceReconciler reconciles a ClusterReplicatedResource object
+type ClusterReplicatedResourceReconciler struct {
+	client.Client
+	Log    logr.Logger
+	Scheme *runtime.Scheme
+	// Replicators holds the kinds with a dedicated replicator, defaults
+	// to replicator.NewRegistry. Other kinds are replicated generically.
+	Replicators *replicator.Registry
+	// Recorder records events, defaults to the manager's recorder.
+	Recorder record.EventRecorder
+	// ResyncInterval is how often ClusterReplicatedResources are
+	// replicated again when nothing changes, defaults to 30 minutes.
+	ResyncInterval time.Duration
+	// CompatibilityAnnotations accepts the Reflector annotations of the
+	// source as consent to its replication.
+	CompatibilityAnnotations bool
+
+	kinds *kindWatches
+}
+
+// +kubebuilder:rbac:groups=utils.simopolis.xyz,resources=clusterreplicatedresources,verbs=get;list;watch;create;update;patch;delete
+// +kubebuilder:rbac:groups=utils.simopolis.xyz,resources=clusterreplicatedresources/status,verbs=get;update;patch
+// +kubebuilder:rbac:groups=utils.simopolis.xyz,resources=clusterreplicatedresources/finalizers,verbs=update
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+func (r *ClusterReplicatedResourceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("clusterreplicatedresource", req.Name)
+
+	crr := &utilsv1alpha1.ClusterReplicatedResource{}
+	if err := r.Get(ctx, req.NamespacedName, crr); err != nil {
+		if !errors.IsNotFound(err) {
+			return ctrl.Result{}, err
+		} else {
+			log.Info("Could not find ClusterReplicatedResource. Ignoring since object must be deleted.")
+			forgetOwnerMetrics("ClusterReplicatedResource", "", req.Name)
+			return ctrl.Result{}, nil
+		}
+	}
+	log.Info("Started Processing")
+
+	if !crr.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, r.finalize(ctx, crr)
+	}
+	if controllerutil.AddFinalizer(crr, cleanupFinalizer) {
+		if err := r.Update(ctx, crr); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	sourceNamespacedName := types.NamespacedName{Namespace: crr.Spec.Source.Namespace, Name: crr.Spec.Source.Name}
+	var replicateErrors []error
+	var drifted, conflicts []string
+	namespaces := crr.Status.Namespaces
+
+	sourceMissing := false
+	kindReplicator, err := r.kinds.ReplicatorFor(crr.Spec.Source.GroupVersionKind())
+	if crr.Spec.Source.ClusterRef != nil {
+		replicateErrors = append(replicateErrors, fmt.Errorf("Only ReplicatedResources can read a source from another cluster"))
+	} else if err != nil {
+		replicateErrors = append(replicateErrors, err)
+	} else if source, err := kindReplicator.Fetch(ctx, r.Client, sourceNamespacedName); err != nil {
+		recordReplication(r.Recorder, crr, "", crr.Spec.DriftPolicy, replicator.Result{}, err)
+		recordReplicationMetrics(crr.Spec.Source.GroupVersionKind().GroupKind().String(), crr.Spec.DriftPolicy, replicator.Result{}, err)
+		// Keep the existing copies until the source is readable again,
+		// or as long as onSourceDeleted allows if it was deleted
+		replicateErrors = append(replicateErrors, err)
+		sourceMissing = errors.IsNotFound(err)
+	} else {
+		namespaces, drifted, conflicts, replicateErrors = r.replicate(ctx, log, crr, kindReplicator, source)
+	}
+
+	now := v1.Now()
+	requeueAfter := r.ResyncInterval
+	crr.Status.SourceNotFoundSince = trackSourceNotFound(crr.Status.SourceNotFoundSince, sourceMissing, now)
+	if remove, after := sourceDeletionDue(crr.Spec.OnSourceDeleted, crr.Spec.SourceDeletedGracePeriod, crr.Status.SourceNotFoundSince, now.Time); remove {
+		var kept []string
+		for _, namespace := range namespaces {
+			key := types.NamespacedName{Namespace: namespace, Name: destinationName(crr)}
+			if err := r.removeDestination(ctx, crr, kindReplicator, key); err != nil {
+				replicateErrors = append(replicateErrors, fmt.Errorf("namespace %s: %w", namespace, err))
+				kept = append(kept, namespace)
+				continue
+			}
+			r.Recorder.Eventf(crr, corev1.EventTypeNormal, eventDestinationDeleted, "Deleted %s as the source was deleted", key)
+		}
+		namespaces = kept
+	} else if after > 0 && after < requeueAfter {
+		requeueAfter = after
+	}
+
+	var replicateError error
+	if len(replicateErrors) > 0 {
+		replicateError = utilerrors.NewAggregate(replicateErrors)
+		recordForbidden(r.Recorder, crr, replicateError)
+		crr.Status.Phase = "Failed"
+	} else {
+		crr.Status.Phase = "Completed"
+	}
+	setConditions(&crr.Status.Conditions, crr.Generation, replicateError, fmt.Sprintf("Replicated to %d namespaces", len(namespaces)))
+	setDriftCondition(&crr.Status.Conditions, crr.Generation, crr.Spec.DriftPolicy, drifted)
+	setConflictCondition(&crr.Status.Conditions, crr.Generation, conflicts)
+	crr.Status.ObservedGeneration = crr.Generation
+	crr.Status.Namespaces = namespaces
+	recordOwnerMetrics("ClusterReplicatedResource", crr, len(namespaces), len(replicateErrors) == 0)
+
+	if err := r.Status().Update(ctx, crr); err != nil {
+		log.Info(fmt.Sprintf("Error updating ClusterReplicatedResource: %s", err))
+		return ctrl.Result{RequeueAfter: requeueAfter}, err
+	}
+
+	log.Info("Successfully Replicated")
+
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// replicate copies the source to every selected namespace and removes the
+// copies from namespaces that are no longer selected. It returns the
+// namespaces that may still hold a copy, the copies that drifted and the
+// existing objects that conflicted with a copy.
+func (r *ClusterReplicatedResourceReconciler) replicate(ctx context.Context, log logr.Logger, crr *utilsv1alpha1.ClusterReplicatedResource, kindReplicator replicator.Replicator, source client.Object) ([]string, []string, []string, []error) {
+	selected, err := r.destinationNamespaces(ctx, crr)
+	if err != nil {
+		return crr.Status.Namespaces, nil, nil, []error{err}
+	}
+
+	var replicateErrors []error
+	var drifted, conflicts []string
+	sourceNamespacedName := client.ObjectKeyFromObject(source)
+
+	namespaces := []string{}
+	for _, namespace := range selected {
+		destNamespacedName := types.NamespacedName{Namespace: namespace, Name: destinationName(crr)}
+		if destNamespacedName == sourceNamespacedName {
+			continue
+		}
+		replication := &replicator.Replication{
+			Source:      sourceNamespacedName,
+			Destination: destNamespacedName,
+			OwnerReferences: []v1.OwnerReference{
+				*v1.NewControllerRef(crr, utilsv1alpha1.GroupVersion.WithKind("ClusterReplicatedResource")),
+			},
+			Fields:                   crr.Spec.Fields,
+			Labels:                   crr.Spec.Destination.Labels,
+			Annotations:              crr.Spec.Destination.Annotations,
+			SourceMetadata:           crr.Spec.Destination.SourceMetadata,
+			DriftPolicy:              crr.Spec.DriftPolicy,
+			Transform:                crr.Spec.Transform,
+			ConflictPolicy:           crr.Spec.ConflictPolicy,
+			CompatibilityAnnotations: r.CompatibilityAnnotations,
+		}
+		result, err := replicator.ReplicateFrom(ctx, r.Client, log, kindReplicator, replication, source)
+		if !result.Conflict {
+			namespaces = append(namespaces, namespace)
+		}
+		recordReplication(r.Recorder, crr, destNamespacedName.String(), crr.Spec.DriftPolicy, result, err)
+		recordReplicationMetrics(crr.Spec.Source.GroupVersionKind().GroupKind().String(), crr.Spec.DriftPolicy, result, err)
+		if err != nil {
+			replicateErrors = append(replicateErrors, fmt.Errorf("namespace %s: %w", namespace, err))
+		}
+		if result.Drifted {
+			drifted = append(drifted, destNamespacedName.String())
+		}
+		if result.Conflict {
+			conflicts = append(conflicts, destNamespacedName.String())
+		}
+	}
+
+	for _, namespace := range crr.Status.Namespaces {
+		if slices.Contains(namespaces, namespace) {
+			continue
+		}
+		log.Info(fmt.Sprintf("Namespace %s is no longer selected, removing copy", namespace))
+		if err := r.removeDestination(ctx, crr, kindReplicator, types.NamespacedName{Namespace: namespace, Name: destinationName(crr)}); err != nil {
+			replicateErrors = append(replicateErrors, fmt.Errorf("namespace %s: %w", namespace, err))
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	sort.Strings(namespaces)
+
+	return namespaces, drifted, conflicts, replicateErrors
+}
+
+// destinationName returns the name of the copies of crr.
+func destinationName(crr *utilsv1alpha1.ClusterReplicatedResource) string {
+	if crr.Spec.Destination.Name != "" {
+		return crr.Spec.Destination.Name
+	}
+	return crr.Name
+}
+
+// destinationNamespaces returns the sorted names of the namespaces selected
+// by the destination of crr.
+func (r *ClusterReplicatedResourceReconciler) destinationNamespaces(ctx context.Context, crr *utilsv1alpha1.ClusterReplicatedResource) ([]string, error) {
+	destination := crr.Spec.Destination
+	selector := labels.Nothing()
+	if destination.NamespaceSelector != nil {
+		s, err := v1.LabelSelectorAsSelector(destination.NamespaceSelector)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid namespace selector: %w", err)
+		}
+		selector = s
+	}
+
+	namespaceList := &corev1.NamespaceList{}
+	if err := r.List(ctx, namespaceList); err != nil {
+		return nil, err
+	}
+
+	var namespaces []string
+	for _, namespace := range namespaceList.Items {
+		if namespace.Status.Phase == corev1.NamespaceTerminating {
+			continue
+		}
+		if slices.Contains(destination.ExcludedNamespaces, namespace.Name) {
+			continue
+		}
+		if selector.Matches(labels.Set(namespace.Labels)) || slices.Contains(destination.Namespaces, namespace.Name) {
+			namespaces = append(namespaces, namespace.Name)
+		}
+	}
+	sort.Strings(namespaces)
+	return namespaces, nil
+}
+
+// removeDestination deletes the copy named key if it is controlled by crr.
+func (r *ClusterReplicatedResourceReconciler) removeDestination(ctx context.Context, crr *utilsv1alpha1.ClusterReplicatedResource, kindReplicator replicator.Replicator, key types.NamespacedName) error {
+	return cleanupDestination(ctx, r.Client, crr, utilsv1alpha1.DeletionDelete, kindReplicator.NewObject(), key)
+}
+
+// finalize deletes or releases the copies of crr, according to its
+// deletion policy, and removes the finalizer.
+func (r *ClusterReplicatedResourceReconciler) finalize(ctx context.Context, crr *utilsv1alpha1.ClusterReplicatedResource) error {
+	if !controllerutil.ContainsFinalizer(crr, cleanupFinalizer) {
+		return nil
+	}
+
+	kindReplicator, err := r.kinds.ReplicatorFor(crr.Spec.Source.GroupVersionKind())
+	switch {
+	case meta.IsNoMatchError(err):
+		// The kind was uninstalled along with the copies
+	case err != nil:
+		return err
+	default:
+		for _, namespace := range crr.Status.Namespaces {
+			key := types.NamespacedName{Namespace: namespace, Name: destinationName(crr)}
+			if err := cleanupDestination(ctx, r.Client, crr, crr.Spec.DeletionPolicy, kindReplicator.NewObject(), key); err != nil {
+				return err
+			}
+		}
+	}
+
+	forgetOwnerMetrics("ClusterReplicatedResource", "", crr.Name)
+	controllerutil.RemoveFinalizer(crr, cleanupFinalizer)
+	return r.Update(ctx, crr)
+}
+
+func (r *ClusterReplicatedResourceReconciler) findObjectsForKind(objKind string) handler.MapFunc {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		attachedClusterReplicatedResource := &utilsv1alpha1.ClusterReplicatedResourceList{}
+
+		r.Log.Info(fmt.Sprintf("Dependent %s/%s of kind %s updated triggering a refresh", obj.GetNamespace(), obj.GetName(), objKind))
+
+		if err := r.List(ctx, attachedClusterReplicatedResource, sourceListOptions(obj, objKind)); err != nil {
+			return []reconcile.Request{}
+		}
+		return clusterReplicatedResourceRequests(attachedClusterReplicatedResource)
+	}
+}
+
+// findObjectsForNamespace requests every ClusterReplicatedResource, as
+// any of them might select a new or relabelled namespace.
+func (r *ClusterReplicatedResourceReconciler) findObjectsForNamespace(ctx context.Context, obj client.Object) []reconcile.Request {
+	clusterReplicatedResources := &utilsv1alpha1.ClusterReplicatedResourceList{}
+	if err := r.List(ctx, clusterReplicatedResources); err != nil {
+		return []reconcile.Request{}
+	}
+	return clusterReplicatedResourceRequests(clusterReplicatedResources)
+}
+
+func clusterReplicatedResourceRequests(list *utilsv1alpha1.ClusterReplicatedResourceList) []reconcile.Request {
+	requests := make([]reconcile.Request, len(list.Items))
+	for i, item := range list.Items {
+		requests[i] = reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: item.GetName()},
+		}
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager.
+func (r *ClusterReplicatedResourceReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	if err := indexSources(mgr, &utilsv1alpha1.ClusterReplicatedResource{}, func(rawObj client.Object) []utilsv1alpha1.ReplicatedResourceSource {
+		return []utilsv1alpha1.ReplicatedResourceSource{rawObj.(*utilsv1alpha1.ClusterReplicatedResource).Spec.Source}
+	}); err != nil {
+		return err
+	}
+
+	if r.Replicators == nil {
+		r.Replicators = replicator.NewRegistry(r.Log)
+	}
+	if r.Recorder == nil {
+		r.Recorder = mgr.GetEventRecorderFor("clusterreplicatedresource-controller")
+	}
+	if r.ResyncInterval == 0 {
+		r.ResyncInterval = defaultResyncInterval
+	}
+	r.kinds = &kindWatches{
+		Replicators:        r.Replicators,
+		Log:                r.Log,
+		Owner:              &utilsv1alpha1.ClusterReplicatedResource{},
+		FindObjectsForKind: r.findObjectsForKind,
+	}
+	c, err := r.kinds.Setup(mgr, ctrl.NewControllerManagedBy(mgr).
+		Named("ClusterReplicatedResource").
+		For(&utilsv1alpha1.ClusterReplicatedResource{}).
+		Watches(
+			&corev1.Namespace{},
+			handler.EnqueueRequestsFromMapFunc(r.findObjectsForNamespace),
+			builder.WithPredicates(predicate.LabelChangedPredicate{}),
+		)).
+		Build(r)
+	if err != nil {
+		return err
+	}
+	r.kinds.Started(c)
+	return nil
+}
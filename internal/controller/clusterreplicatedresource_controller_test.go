@@ -0,0 +1,141 @@
+/*
+Copyright 2021-2022 Russell Sim <russell.sim@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"time"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	utilsv1alpha1 "github.com/russell/resource-replication-operator/api/v1alpha1"
+	"github.com/russell/resource-replication-operator/replicator/common"
+)
+
+var _ = Describe("ClusterReplicatedResource controller", func() {
+
+	const (
+		ClusterReplicatedResourceName = "test-cluster-replicated-secret"
+		SecretName                    = "test-cluster-secret"
+		SecretNamespace               = "default"
+
+		timeout  = time.Second * 10
+		interval = time.Millisecond * 250
+	)
+
+	createNamespace := func(ctx context.Context, name string, labels map[string]string) *corev1.Namespace {
+		namespace := &corev1.Namespace{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:   name,
+				Labels: labels,
+			},
+		}
+		Expect(k8sClient.Create(ctx, namespace)).Should(Succeed())
+		return namespace
+	}
+
+	Context("When creating ClusterReplicatedResource", func() {
+		It("Should replicate a secret to every selected namespace", func() {
+			ctx := context.Background()
+
+			By("By creating namespaces")
+			createNamespace(ctx, "payments-a", map[string]string{"team": "payments"})
+			createNamespace(ctx, "payments-excluded", map[string]string{"team": "payments"})
+			explicit := createNamespace(ctx, "explicit", nil)
+			other := createNamespace(ctx, "other", map[string]string{"team": "other"})
+
+			By("By creating a new Secret")
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      SecretName,
+					Namespace: SecretNamespace,
+					Annotations: map[string]string{
+						common.AllowedNamespacesRegexAnnotation: ".*",
+					},
+				},
+				Data: map[string][]byte{
+					"tls.crt": []byte("certificate"),
+				},
+			}
+			Expect(k8sClient.Create(ctx, secret)).Should(Succeed())
+
+			By("By creating a new ClusterReplicatedResource")
+			clusterReplicatedResource := &utilsv1alpha1.ClusterReplicatedResource{
+				ObjectMeta: metav1.ObjectMeta{
+					Name: ClusterReplicatedResourceName,
+				},
+				Spec: utilsv1alpha1.ClusterReplicatedResourceSpec{
+					Source: utilsv1alpha1.ReplicatedResourceSource{
+						Namespace: SecretNamespace,
+						Name:      SecretName,
+						Kind:      "Secret",
+					},
+					Destination: utilsv1alpha1.ClusterReplicatedResourceDestination{
+						NamespaceSelector: &metav1.LabelSelector{
+							MatchLabels: map[string]string{"team": "payments"},
+						},
+						Namespaces:         []string{explicit.Name},
+						ExcludedNamespaces: []string{"payments-excluded"},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, clusterReplicatedResource)).Should(Succeed())
+
+			getCopy := func(namespace string) func() error {
+				return func() error {
+					return k8sClient.Get(ctx, types.NamespacedName{Name: ClusterReplicatedResourceName, Namespace: namespace}, &corev1.Secret{})
+				}
+			}
+
+			Eventually(getCopy("payments-a"), timeout, interval).Should(Succeed())
+			Eventually(getCopy(explicit.Name), timeout, interval).Should(Succeed())
+			Consistently(getCopy("payments-excluded"), time.Second, interval).ShouldNot(Succeed())
+			Expect(getCopy(other.Name)()).ShouldNot(Succeed())
+
+			By("By creating a matching namespace")
+			createNamespace(ctx, "payments-b", map[string]string{"team": "payments"})
+			Eventually(getCopy("payments-b"), timeout, interval).Should(Succeed())
+
+			By("By labelling a namespace")
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: other.Name}, other)).Should(Succeed())
+			other.Labels["team"] = "payments"
+			Expect(k8sClient.Update(ctx, other)).Should(Succeed())
+			Eventually(getCopy(other.Name), timeout, interval).Should(Succeed())
+
+			By("By unlabelling a namespace")
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: other.Name}, other)).Should(Succeed())
+			other.Labels["team"] = "other"
+			Expect(k8sClient.Update(ctx, other)).Should(Succeed())
+			Eventually(func() bool {
+				return errors.IsNotFound(getCopy(other.Name)())
+			}, timeout, interval).Should(BeTrue())
+
+			Eventually(func() []string {
+				if err := k8sClient.Get(ctx, types.NamespacedName{Name: ClusterReplicatedResourceName}, clusterReplicatedResource); err != nil {
+					return nil
+				}
+				return clusterReplicatedResource.Status.Namespaces
+			}, timeout, interval).Should(Equal([]string{explicit.Name, "payments-a", "payments-b"}))
+		})
+	})
+})
@@ -0,0 +1,182 @@
+/*
+Copyright 2021-2022 Russell Sim <russell.sim@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+
+	utilsv1alpha1 "github.com/russell/resource-replication-operator/api/v1alpha1"
+	"github.com/russell/resource-replication-operator/replicator"
+)
+
+// Reasons that aren't the reason of a replicator.Error.
+const (
+	reasonReplicated     = "Replicated"
+	reasonSourceNotFound = "SourceNotFound"
+	reasonFound          = "Found"
+	reasonAuthorized     = "Authorized"
+)
+
+// deniedReasons are the reasons of errors that refuse a replication.
+var deniedReasons = map[string]bool{
+	"Forbidden":    true,
+	"PolicyDenied": true,
+	"Unauthorized": true,
+}
+
+// fetchedReasons are the reasons of errors returned once the source was
+// fetched and the replication authorized.
+var fetchedReasons = map[string]bool{
+	"MissingKey":       true,
+	"InvalidTransform": true,
+	"TemplateError":    true,
+	"KeyConflict":      true,
+	"InvalidSource":    true,
+	// The destination is only checked once the source is replicated.
+	"DestinationConflict": true,
+	// Remote clusters are only replicated to once the local copy is.
+	reasonClusterNotFound:    true,
+	reasonClusterForbidden:   true,
+	reasonClusterUnavailable: true,
+}
+
+// setConditions sets the Ready, SourceFound, Authorized and Synced
+// conditions from the outcome of a replication, err being nil if it
+// succeeded. Conditions whose status doesn't change keep their transition
+// time.
+func setConditions(conditions *[]metav1.Condition, generation int64, err error, message string) {
+	set := func(conditionType string, status metav1.ConditionStatus, reason, message string) {
+		meta.SetStatusCondition(conditions, metav1.Condition{
+			Type:               conditionType,
+			Status:             status,
+			ObservedGeneration: generation,
+			Reason:             reason,
+			Message:            message,
+		})
+	}
+
+	if err == nil {
+		set(utilsv1alpha1.ReplicatedResourceSourceFound, metav1.ConditionTrue, reasonFound, "Source found")
+		set(utilsv1alpha1.ReplicatedResourceAuthorized, metav1.ConditionTrue, reasonAuthorized, "Replication is allowed")
+		set(utilsv1alpha1.ReplicatedResourceSynced, metav1.ConditionTrue, reasonReplicated, message)
+		set(utilsv1alpha1.ReplicatedResourceReady, metav1.ConditionTrue, reasonReplicated, message)
+		return
+	}
+
+	errs := []error{err}
+	var aggregate utilerrors.Aggregate
+	if errors.As(err, &aggregate) {
+		errs = aggregate.Errors()
+	}
+
+	// Each condition takes the worst status among the errors: False, then
+	// Unknown, then True.
+	sourceFound := metav1.Condition{Status: metav1.ConditionTrue, Reason: reasonFound, Message: "Source found"}
+	authorized := metav1.Condition{Status: metav1.ConditionTrue, Reason: reasonAuthorized, Message: "Replication is allowed"}
+	worsen := func(condition *metav1.Condition, status metav1.ConditionStatus, reason, message string) {
+		if condition.Status == metav1.ConditionFalse || (condition.Status == metav1.ConditionUnknown && status != metav1.ConditionFalse) {
+			return
+		}
+		condition.Status, condition.Reason, condition.Message = status, reason, message
+	}
+	syncReason := ""
+	for _, err := range errs {
+		reason := replicator.ReasonFor(err)
+		switch {
+		case kerrors.IsNotFound(err):
+			reason = reasonSourceNotFound
+			worsen(&sourceFound, metav1.ConditionFalse, reason, err.Error())
+			worsen(&authorized, metav1.ConditionUnknown, reason, "Source not found")
+		case deniedReasons[reason]:
+			if reason != "Forbidden" {
+				// The source is only fetched once replicating it is allowed.
+				worsen(&sourceFound, metav1.ConditionUnknown, reason, "Replication isn't allowed")
+			}
+			worsen(&authorized, metav1.ConditionFalse, reason, err.Error())
+		case !fetchedReasons[reason]:
+			worsen(&sourceFound, metav1.ConditionUnknown, reason, err.Error())
+			worsen(&authorized, metav1.ConditionUnknown, reason, err.Error())
+		}
+		if syncReason == "" {
+			syncReason = reason
+		}
+	}
+
+	set(utilsv1alpha1.ReplicatedResourceSourceFound, sourceFound.Status, sourceFound.Reason, sourceFound.Message)
+	set(utilsv1alpha1.ReplicatedResourceAuthorized, authorized.Status, authorized.Reason, authorized.Message)
+	set(utilsv1alpha1.ReplicatedResourceSynced, metav1.ConditionFalse, syncReason, err.Error())
+	set(utilsv1alpha1.ReplicatedResourceReady, metav1.ConditionFalse, syncReason, err.Error())
+}
+
+// setDriftCondition sets the Drifted condition given the destinations that
+// drifted. Corrected drift is recorded as a False condition that is kept
+// until the next drift, and the condition isn't set if there never was any.
+func setDriftCondition(conditions *[]metav1.Condition, generation int64, policy utilsv1alpha1.DriftPolicy, drifted []string) {
+	existing := meta.FindStatusCondition(*conditions, utilsv1alpha1.ReplicatedResourceDrifted)
+
+	condition := metav1.Condition{Type: utilsv1alpha1.ReplicatedResourceDrifted, ObservedGeneration: generation}
+	switch {
+	case len(drifted) > 0 && policy == utilsv1alpha1.DriftReportOnly:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "DriftDetected"
+		condition.Message = fmt.Sprintf("Modified since replicated: %s", strings.Join(drifted, ", "))
+	case len(drifted) > 0:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "DriftCorrected"
+		condition.Message = fmt.Sprintf("Modified since replicated and corrected: %s", strings.Join(drifted, ", "))
+	case existing == nil:
+		return
+	case existing.Status == metav1.ConditionTrue:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "InSync"
+		condition.Message = "Destination matches the source"
+	default:
+		existing.ObservedGeneration = generation
+		return
+	}
+	meta.SetStatusCondition(conditions, condition)
+}
+
+// setConflictCondition sets the DestinationConflict condition given the
+// destinations that exist and couldn't be adopted. Resolved conflicts are
+// recorded as a False condition, and the condition isn't set if there never
+// was any.
+func setConflictCondition(conditions *[]metav1.Condition, generation int64, conflicts []string) {
+	existing := meta.FindStatusCondition(*conditions, utilsv1alpha1.ReplicatedResourceDestinationConflict)
+
+	condition := metav1.Condition{Type: utilsv1alpha1.ReplicatedResourceDestinationConflict, ObservedGeneration: generation}
+	switch {
+	case len(conflicts) > 0:
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "DestinationConflict"
+		condition.Message = fmt.Sprintf("Not managed by this replication: %s", strings.Join(conflicts, ", "))
+	case existing == nil:
+		return
+	default:
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "NoConflict"
+		condition.Message = "Destinations are managed by this replication"
+	}
+	meta.SetStatusCondition(conditions, condition)
+}
@@ -0,0 +1,147 @@
+/*
+Copyright 2021-2022 Russell Sim <russell.sim@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	utilsv1alpha1 "github.com/russell/resource-replication-operator/api/v1alpha1"
+	"github.com/russell/resource-replication-operator/replicator"
+)
+
+// Reasons of the events recorded by the controllers, besides Forbidden
+// which is the reason of the replication error.
+const (
+	eventReplicated          = "Replicated"
+	eventSourceNotFound      = "SourceNotFound"
+	eventSourceChanged       = "SourceChanged"
+	eventDriftCorrected      = "DriftCorrected"
+	eventDestinationConflict = "DestinationConflict"
+	eventDestinationDeleted  = "DestinationDeleted"
+	eventDestinationAdopted  = "DestinationAdopted"
+	eventWorkloadRestarted   = "WorkloadRestarted"
+)
+
+// recordReplication records the events of replicating the sources of owner
+// to destination. Replicated is also recorded on the sources, unless owner
+// is the source, so that they show where they were copied to.
+func recordReplication(recorder record.EventRecorder, owner client.Object, destination string, policy utilsv1alpha1.DriftPolicy, result replicator.Result, err error) {
+	if err != nil {
+		if kerrors.IsNotFound(err) {
+			recorder.Event(owner, corev1.EventTypeWarning, eventSourceNotFound, err.Error())
+		}
+		if result.Conflict {
+			recorder.Event(owner, corev1.EventTypeWarning, eventDestinationConflict, err.Error())
+		}
+		return
+	}
+
+	if result.SourceChanged {
+		revisions := make([]string, 0, len(result.Sources))
+		for _, source := range result.Sources {
+			revisions = append(revisions, fmt.Sprintf("%s at resourceVersion %s", client.ObjectKeyFromObject(source), source.GetResourceVersion()))
+		}
+		recorder.Eventf(owner, corev1.EventTypeNormal, eventSourceChanged, "Source changed, replicating %s", strings.Join(revisions, ", "))
+	}
+	if result.Adopted {
+		recorder.Eventf(owner, corev1.EventTypeNormal, eventDestinationAdopted, "Adopted existing destination %s", destination)
+	}
+	if driftCorrected(policy, result) {
+		recorder.Eventf(owner, corev1.EventTypeWarning, eventDriftCorrected, "Destination %s was modified and has been corrected", destination)
+	}
+	if result.Operation == controllerutil.OperationResultCreated || result.Operation == controllerutil.OperationResultUpdated {
+		recorder.Eventf(owner, corev1.EventTypeNormal, eventReplicated, "Replicated to %s", destination)
+		for _, source := range result.Sources {
+			if source == owner {
+				continue
+			}
+			recorder.Eventf(source, corev1.EventTypeNormal, eventReplicated, "Replicated to %s by %s", destination, describeOwner(owner))
+		}
+	}
+}
+
+// recordForbidden records a Forbidden event on owner and on the source
+// that refused replication for every Forbidden replication error in err.
+func recordForbidden(recorder record.EventRecorder, owner client.Object, err error) {
+	errs := []error{err}
+	var aggregate utilerrors.Aggregate
+	if errors.As(err, &aggregate) {
+		errs = aggregate.Errors()
+	}
+	for _, err := range errs {
+		var replicationErr *replicator.Error
+		if !errors.As(err, &replicationErr) || replicationErr.Reason != "Forbidden" {
+			continue
+		}
+		recorder.Event(owner, corev1.EventTypeWarning, replicationErr.Reason, replicationErr.Message)
+		if replicationErr.Object != nil {
+			recorder.Event(replicationErr.Object, corev1.EventTypeWarning, replicationErr.Reason, replicationErr.Message)
+		}
+	}
+}
+
+// driftCorrected reports whether result overwrote a modified destination.
+func driftCorrected(policy utilsv1alpha1.DriftPolicy, result replicator.Result) bool {
+	return result.Drifted && policy != utilsv1alpha1.DriftReportOnly && policy != utilsv1alpha1.DriftIgnore
+}
+
+// describeOwner returns the kind and name of owner, as typed objects read
+// from the cache have no TypeMeta.
+func describeOwner(owner client.Object) string {
+	switch owner.(type) {
+	case *utilsv1alpha1.ReplicatedResource:
+		return fmt.Sprintf("ReplicatedResource %s", client.ObjectKeyFromObject(owner))
+	case *utilsv1alpha1.ClusterReplicatedResource:
+		return fmt.Sprintf("ClusterReplicatedResource %s", owner.GetName())
+	}
+	return client.ObjectKeyFromObject(owner).String()
+}
+
+// ownerRecorder only records the events of owner, for replications whose
+// sources are in another cluster.
+type ownerRecorder struct {
+	record.EventRecorder
+	owner client.Object
+}
+
+func (r ownerRecorder) Event(object runtime.Object, eventtype, reason, message string) {
+	if object == r.owner {
+		r.EventRecorder.Event(object, eventtype, reason, message)
+	}
+}
+
+func (r ownerRecorder) Eventf(object runtime.Object, eventtype, reason, messageFmt string, args ...interface{}) {
+	if object == r.owner {
+		r.EventRecorder.Eventf(object, eventtype, reason, messageFmt, args...)
+	}
+}
+
+func (r ownerRecorder) AnnotatedEventf(object runtime.Object, annotations map[string]string, eventtype, reason, messageFmt string, args ...interface{}) {
+	if object == r.owner {
+		r.EventRecorder.AnnotatedEventf(object, annotations, eventtype, reason, messageFmt, args...)
+	}
+}
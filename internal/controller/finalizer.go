@@ -0,0 +1,49 @@
+/*
+Copyright 2021-2022 Russell Sim <russell.sim@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	utilsv1alpha1 "github.com/russell/resource-replication-operator/api/v1alpha1"
+	"github.com/russell/resource-replication-operator/replicator"
+)
+
+// cleanupFinalizer is set on ReplicatedResources and
+// ClusterReplicatedResources so that their destinations are deleted or
+// released, according to their deletion policy, before they are removed.
+const cleanupFinalizer = "replicated-resource.simopolis.xyz/cleanup"
+
+// cleanupDestination deletes the destination key, or releases it when
+// policy is Orphan, if it's controlled by owner. dest is an empty object of
+// the kind of the destination.
+func cleanupDestination(ctx context.Context, c client.Client, owner client.Object, policy utilsv1alpha1.DeletionPolicy, dest client.Object, key types.NamespacedName) error {
+	if err := c.Get(ctx, key, dest); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if !v1.IsControlledBy(dest, owner) {
+		return nil
+	}
+	if policy == utilsv1alpha1.DeletionOrphan {
+		return client.IgnoreNotFound(replicator.Release(ctx, c, dest, owner))
+	}
+	return client.IgnoreNotFound(c.Delete(ctx, dest))
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2021-2022 Russell Sim <russell.sim@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	utilsv1alpha1 "github.com/russell/resource-replication-operator/api/v1alpha1"
+	"github.com/russell/resource-replication-operator/replicator"
+)
+
+// sourceField indexes the sources of an object as group qualified kind,
+// namespace and name, e.g. Secret/certificates/wildcard-tls.
+const sourceField = ".spec.sources"
+
+// sourceIndexValue returns the value indexed under sourceField for the
+// object of the group qualified kind objKind with the given key.
+func sourceIndexValue(objKind string, key types.NamespacedName) string {
+	return fmt.Sprintf("%s/%s", objKind, key)
+}
+
+// indexSources indexes every source of obj so that objects can be found by
+// the sources they replicate.
+func indexSources(mgr ctrl.Manager, obj client.Object, sourcesOf func(client.Object) []utilsv1alpha1.ReplicatedResourceSource) error {
+	return mgr.GetFieldIndexer().IndexField(context.Background(), obj, sourceField, func(rawObj client.Object) []string {
+		var values []string
+		for _, source := range sourcesOf(rawObj) {
+			// Sources in other clusters are watched by remotecluster.Sources
+			if source.Kind == "" || source.Name == "" || source.ClusterRef != nil {
+				continue
+			}
+			key := types.NamespacedName{Namespace: source.Namespace, Name: source.Name}
+			values = append(values, sourceIndexValue(source.GroupVersionKind().GroupKind().String(), key))
+		}
+		return values
+	})
+}
+
+// sourceListOptions selects the objects indexed by indexSources that
+// replicate obj, objKind is the group qualified kind of obj.
+func sourceListOptions(obj client.Object, objKind string) *client.ListOptions {
+	return &client.ListOptions{
+		FieldSelector: fields.OneTermEqualSelector(sourceField, sourceIndexValue(objKind, client.ObjectKeyFromObject(obj))),
+	}
+}
+
+// sourcePredicate passes the source events that can change a replication:
+// creations, deletions, which are handled according to onSourceDeleted,
+// and updates that change the resource version.
+var sourcePredicate = predicate.Funcs{
+	UpdateFunc: predicate.ResourceVersionChangedPredicate{}.Update,
+	DeleteFunc: func(event.DeleteEvent) bool { return true },
+}
+
+// kindWatches resolves the replicator for a source kind. The kinds in
+// Replicators are watched from the start, other kinds are replicated
+// generically and watched the first time they are referenced.
+type kindWatches struct {
+	Replicators *replicator.Registry // required
+	Log         logr.Logger
+	// Owner is an empty object of the type that owns the destinations.
+	Owner client.Object
+	// FindObjectsForKind maps source objects of a group qualified kind to
+	// the owners replicating them.
+	FindObjectsForKind func(objKind string) handler.MapFunc
+
+	mgr        ctrl.Manager
+	controller controller.Controller
+
+	// watched records the kinds without a registered replicator that
+	// have had a source and destination watch registered.
+	watched     map[schema.GroupVersionKind]bool
+	watchedLock sync.Mutex
+}
+
+// Setup adds watches for the registered kinds to b.
+func (w *kindWatches) Setup(mgr ctrl.Manager, b *builder.Builder) *builder.Builder {
+	w.mgr = mgr
+
+	for _, gvk := range w.Replicators.Kinds() {
+		kindReplicator, _ := w.Replicators.Get(gvk)
+		b = b.Owns(kindReplicator.NewObject()).
+			Watches(
+				kindReplicator.NewObject(),
+				handler.EnqueueRequestsFromMapFunc(w.FindObjectsForKind(gvk.GroupKind().String())),
+				builder.WithPredicates(sourcePredicate),
+			)
+	}
+	return b
+}
+
+// Started records the controller that dynamic watches are added to.
+func (w *kindWatches) Started(c controller.Controller) {
+	w.controller = c
+}
+
+// ReplicatorFor returns the replicator for gvk, falling back to the
+// generic replicator for kinds that don't have a registered one.
+func (w *kindWatches) ReplicatorFor(gvk schema.GroupVersionKind) (replicator.Replicator, error) {
+	if kindReplicator, ok := w.Replicators.Get(gvk); ok {
+		return kindReplicator, nil
+	}
+	if gvk.Kind == "" {
+		return nil, fmt.Errorf("Unsupported kind %s", gvk)
+	}
+	kindReplicator := &replicator.UnstructuredReplicator{Log: w.Log.WithValues("type", gvk.Kind), GVK: gvk}
+	if err := w.watchKind(kindReplicator, gvk); err != nil {
+		return nil, err
+	}
+	return kindReplicator, nil
+}
+
+// watchKind registers watches for the source and destination objects of a
+// kind without a registered replicator, the first time it is referenced.
+func (w *kindWatches) watchKind(kindReplicator replicator.Replicator, gvk schema.GroupVersionKind) error {
+	w.watchedLock.Lock()
+	defer w.watchedLock.Unlock()
+
+	if w.watched[gvk] {
+		return nil
+	}
+	mapping, err := w.mgr.GetRESTMapper().RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return fmt.Errorf("Unsupported kind %s: %w", gvk, err)
+	}
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		return fmt.Errorf("Unsupported kind %s: only namespaced kinds can be replicated", gvk)
+	}
+
+	if err := w.controller.Watch(source.Kind(w.mgr.GetCache(), kindReplicator.NewObject(),
+		handler.EnqueueRequestsFromMapFunc(w.FindObjectsForKind(gvk.GroupKind().String())),
+		sourcePredicate)); err != nil {
+		return err
+	}
+
+	if err := w.controller.Watch(source.Kind(w.mgr.GetCache(), kindReplicator.NewObject(),
+		handler.EnqueueRequestForOwner(w.mgr.GetScheme(), w.mgr.GetRESTMapper(), w.Owner, handler.OnlyControllerOwner()))); err != nil {
+		return err
+	}
+
+	if w.watched == nil {
+		w.watched = make(map[schema.GroupVersionKind]bool)
+	}
+	w.watched[gvk] = true
+	w.Log.Info(fmt.Sprintf("Watching %s", gvk))
+	return nil
+}
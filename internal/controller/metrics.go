@@ -0,0 +1,152 @@
+/*
+Copyright 2021-2022 Russell Sim <russell.sim@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/metrics"
+
+	utilsv1alpha1 "github.com/russell/resource-replication-operator/api/v1alpha1"
+	"github.com/russell/resource-replication-operator/replicator"
+)
+
+var (
+	replicationsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "resource_replication_replications_total",
+			Help: "Number of replications by source kind and result, success or failure.",
+		},
+		[]string{"kind", "result"},
+	)
+	syncLatency = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "resource_replication_sync_latency_seconds",
+			Help:    "Time from a change of the source to the update of its destination.",
+			Buckets: prometheus.ExponentialBuckets(0.1, 2, 14),
+		},
+		[]string{"kind"},
+	)
+	lastSuccessfulSync = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "resource_replication_last_successful_sync_timestamp_seconds",
+			Help: "Unix time of the last successful replication of a ReplicatedResource or ClusterReplicatedResource.",
+		},
+		[]string{"owner_kind", "namespace", "name"},
+	)
+	managedDestinations = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "resource_replication_destinations",
+			Help: "Number of destinations managed by a ReplicatedResource or ClusterReplicatedResource.",
+		},
+		[]string{"owner_kind", "namespace", "name"},
+	)
+	driftCorrections = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "resource_replication_drift_corrections_total",
+			Help: "Number of modified destinations that were overwritten, by source kind.",
+		},
+		[]string{"kind"},
+	)
+	policyDenials = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "resource_replication_policy_denials_total",
+			Help: "Number of replications refused by source consent (Forbidden), ReplicationPolicies (PolicyDenied) or access reviews (Unauthorized).",
+		},
+		[]string{"reason"},
+	)
+)
+
+// defaultResyncInterval is how often the controllers replicate again when
+// nothing changes, which keeps lastSuccessfulSync of healthy owners within
+// the ReplicationStale alert threshold.
+const defaultResyncInterval = 30 * time.Minute
+
+func init() {
+	metrics.Registry.MustRegister(
+		replicationsTotal,
+		syncLatency,
+		lastSuccessfulSync,
+		managedDestinations,
+		driftCorrections,
+		policyDenials,
+	)
+}
+
+// recordReplicationMetrics records the outcome of replicating a source of
+// kind, err being nil if it succeeded.
+func recordReplicationMetrics(kind string, policy utilsv1alpha1.DriftPolicy, result replicator.Result, err error) {
+	if err != nil {
+		replicationsTotal.WithLabelValues(kind, "failure").Inc()
+		errs := []error{err}
+		var aggregate utilerrors.Aggregate
+		if errors.As(err, &aggregate) {
+			errs = aggregate.Errors()
+		}
+		for _, err := range errs {
+			if reason := replicator.ReasonFor(err); deniedReasons[reason] {
+				policyDenials.WithLabelValues(reason).Inc()
+			}
+		}
+		return
+	}
+
+	replicationsTotal.WithLabelValues(kind, "success").Inc()
+	if driftCorrected(policy, result) {
+		driftCorrections.WithLabelValues(kind).Inc()
+	}
+	if result.SourceChanged {
+		latency := time.Since(lastModified(result.Sources))
+		syncLatency.WithLabelValues(kind).Observe(max(latency.Seconds(), 0))
+	}
+}
+
+// recordOwnerMetrics records the number of destinations of an owner and,
+// if it replicated successfully, the time of the replication.
+func recordOwnerMetrics(ownerKind string, owner client.Object, destinations int, succeeded bool) {
+	managedDestinations.WithLabelValues(ownerKind, owner.GetNamespace(), owner.GetName()).Set(float64(destinations))
+	if succeeded {
+		lastSuccessfulSync.WithLabelValues(ownerKind, owner.GetNamespace(), owner.GetName()).SetToCurrentTime()
+	}
+}
+
+// forgetOwnerMetrics removes the series of a deleted owner.
+func forgetOwnerMetrics(ownerKind, namespace, name string) {
+	managedDestinations.DeleteLabelValues(ownerKind, namespace, name)
+	lastSuccessfulSync.DeleteLabelValues(ownerKind, namespace, name)
+}
+
+// lastModified returns the last time one of objs was created or updated,
+// according to their managed fields.
+func lastModified(objs []client.Object) time.Time {
+	var modified time.Time
+	for _, obj := range objs {
+		if created := obj.GetCreationTimestamp().Time; created.After(modified) {
+			modified = created
+		}
+		for _, entry := range obj.GetManagedFields() {
+			if entry.Time != nil && entry.Time.After(modified) {
+				modified = entry.Time.Time
+			}
+		}
+	}
+	return modified
+}
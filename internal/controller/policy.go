@@ -0,0 +1,216 @@
+/*
+Copyright 2021-2022 Russell Sim <russell.sim@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"slices"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	utilsv1alpha1 "github.com/russell/resource-replication-operator/api/v1alpha1"
+	"github.com/russell/resource-replication-operator/replicator"
+)
+
+// policyChecker checks replications against the ReplicationPolicies, for
+// both ReplicatedResources and annotated sources.
+type policyChecker struct {
+	client.Reader
+	// defaultDeny denies replicating sources that aren't selected by any
+	// ReplicationPolicy rule.
+	defaultDeny bool
+}
+
+// policyReplication is a replication checked against the
+// ReplicationPolicies.
+type policyReplication struct {
+	// destNamespace is the namespace of the destination.
+	destNamespace string
+	// transforms are the transforms applied to the sources.
+	transforms []utilsv1alpha1.TransformType
+	// fanOutRank returns the rank of the replication among those of a
+	// source for MaxFanOut, given the ReplicatedResources replicating the
+	// source from the oldest.
+	fanOutRank func(replicating []utilsv1alpha1.ReplicatedResource) int
+}
+
+// check returns a PolicyDenied replication error unless every source of
+// replication is allowed by the ReplicationPolicies. Sources that no rule
+// selects are allowed unless defaultDeny is set.
+func (p policyChecker) check(ctx context.Context, sources []utilsv1alpha1.ReplicatedResourceSource, replication policyReplication) error {
+	policies := &utilsv1alpha1.ReplicationPolicyList{}
+	if err := p.List(ctx, policies); err != nil {
+		return err
+	}
+	sort.Slice(policies.Items, func(i, j int) bool {
+		return policies.Items[i].Name < policies.Items[j].Name
+	})
+
+	for _, source := range sources {
+		if err := p.checkSource(ctx, policies.Items, source, replication); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkSource checks a single source of replication, the denial of the
+// first rule selecting the source is returned when none allows it.
+func (p policyChecker) checkSource(ctx context.Context, policies []utilsv1alpha1.ReplicationPolicy, source utilsv1alpha1.ReplicatedResourceSource, replication policyReplication) error {
+	sourceKind := source.GroupVersionKind().GroupKind().String()
+	sourceKey := types.NamespacedName{Namespace: source.Namespace, Name: source.Name}
+
+	var denied error
+	for _, policy := range policies {
+		for _, rule := range policy.Spec.Rules {
+			selected, err := p.selectsSource(ctx, rule.Source, sourceKind, sourceKey)
+			if err != nil {
+				return err
+			}
+			if !selected {
+				continue
+			}
+			reason, err := p.ruleDenies(ctx, rule, sourceKind, sourceKey, replication)
+			if err != nil {
+				return err
+			}
+			if reason == "" {
+				return nil
+			}
+			if denied == nil {
+				denied = &replicator.Error{
+					Reason:  "PolicyDenied",
+					Message: fmt.Sprintf("Denied by ReplicationPolicy %s: %s", policy.Name, reason),
+				}
+			}
+		}
+	}
+	if denied == nil && p.defaultDeny {
+		denied = &replicator.Error{
+			Reason:  "PolicyDenied",
+			Message: fmt.Sprintf("No ReplicationPolicy allows replicating %s %s", sourceKind, sourceKey),
+		}
+	}
+	return denied
+}
+
+// selectsSource returns whether selector selects the source of the group
+// qualified kind sourceKind.
+func (p policyChecker) selectsSource(ctx context.Context, selector utilsv1alpha1.ReplicationPolicySource, sourceKind string, sourceKey types.NamespacedName) (bool, error) {
+	if len(selector.Kinds) > 0 && !slices.Contains(selector.Kinds, sourceKind) {
+		return false, nil
+	}
+	if len(selector.Names) > 0 && !slices.ContainsFunc(selector.Names, func(pattern string) bool {
+		matched, _ := path.Match(pattern, sourceKey.Name)
+		return matched
+	}) {
+		return false, nil
+	}
+	return p.namespaceSelected(ctx, sourceKey.Namespace, selector.Namespaces, selector.NamespaceSelector)
+}
+
+// ruleDenies returns why rule denies replication of the source, or an
+// empty string if it allows it.
+func (p policyChecker) ruleDenies(ctx context.Context, rule utilsv1alpha1.ReplicationPolicyRule, sourceKind string, sourceKey types.NamespacedName, replication policyReplication) (string, error) {
+	selected, err := p.namespaceSelected(ctx, replication.destNamespace, rule.Destination.Namespaces, rule.Destination.NamespaceSelector)
+	if err != nil {
+		return "", err
+	}
+	if !selected {
+		return fmt.Sprintf("namespace %s is not an allowed destination", replication.destNamespace), nil
+	}
+
+	for _, transform := range replication.transforms {
+		if !slices.Contains(rule.AllowedTransforms, transform) {
+			return fmt.Sprintf("transform %s is not allowed", transform), nil
+		}
+	}
+
+	if rule.MaxFanOut != nil {
+		replicating := &utilsv1alpha1.ReplicatedResourceList{}
+		if err := p.List(ctx, replicating, client.MatchingFields{sourceField: sourceIndexValue(sourceKind, sourceKey)}); err != nil {
+			return "", err
+		}
+		items := replicating.Items
+		sort.Slice(items, func(i, j int) bool {
+			if !items[i].CreationTimestamp.Equal(&items[j].CreationTimestamp) {
+				return items[i].CreationTimestamp.Before(&items[j].CreationTimestamp)
+			}
+			return client.ObjectKeyFromObject(&items[i]).String() < client.ObjectKeyFromObject(&items[j]).String()
+		})
+		if replication.fanOutRank(items) >= int(*rule.MaxFanOut) {
+			return fmt.Sprintf("%s %s is already replicated the maximum of %d times", sourceKind, sourceKey, *rule.MaxFanOut), nil
+		}
+	}
+	return "", nil
+}
+
+// namespaceSelected returns whether namespace is listed in names or matches
+// selector, every namespace is selected when both are empty.
+func (p policyChecker) namespaceSelected(ctx context.Context, namespace string, names []string, selector *v1.LabelSelector) (bool, error) {
+	if len(names) == 0 && selector == nil {
+		return true, nil
+	}
+	if slices.Contains(names, namespace) {
+		return true, nil
+	}
+	if selector == nil {
+		return false, nil
+	}
+	parsed, err := v1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return false, err
+	}
+	ns := &corev1.Namespace{}
+	if err := p.Get(ctx, types.NamespacedName{Name: namespace}, ns); err != nil {
+		return false, err
+	}
+	return parsed.Matches(labels.Set(ns.Labels)), nil
+}
+
+// ownerRank returns the rank of the ReplicatedResource uid among
+// replicating, after all of them when it isn't listed.
+func ownerRank(uid types.UID) func([]utilsv1alpha1.ReplicatedResource) int {
+	return func(replicating []utilsv1alpha1.ReplicatedResource) int {
+		rank := slices.IndexFunc(replicating, func(item utilsv1alpha1.ReplicatedResource) bool {
+			return item.UID == uid
+		})
+		if rank < 0 {
+			return len(replicating)
+		}
+		return rank
+	}
+}
+
+// usedTransforms returns the types of the transforms set in transform.
+func usedTransforms(transform utilsv1alpha1.ReplicatedResourceTransform) []utilsv1alpha1.TransformType {
+	var transforms []utilsv1alpha1.TransformType
+	if transform.Keys != nil {
+		transforms = append(transforms, utilsv1alpha1.TransformKeys)
+	}
+	if len(transform.Template) > 0 {
+		transforms = append(transforms, utilsv1alpha1.TransformTemplate)
+	}
+	return transforms
+}
@@ -0,0 +1,264 @@
+/*
+Copyright 2021-2022 Russell Sim <russell.sim@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	utilsv1alpha1 "github.com/russell/resource-replication-operator/api/v1alpha1"
+	"github.com/russell/resource-replication-operator/replicator"
+	"github.com/russell/resource-replication-operator/replicator/common"
+)
+
+// Reasons of the errors of replicating to remote clusters.
+const (
+	reasonClusterNotFound    = "ClusterNotFound"
+	reasonClusterForbidden   = "ClusterForbidden"
+	reasonClusterUnavailable = "ClusterUnavailable"
+)
+
+// selectClusters returns the sorted names of the RemoteClusters selected by
+// selector that allow the ReplicatedResources of namespace. A named cluster
+// that doesn't exist or doesn't allow namespace is an error, while the
+// clusters that only match the label selector are skipped.
+func selectClusters(ctx context.Context, c client.Client, selector *utilsv1alpha1.ClusterSelector, namespace string) ([]string, error) {
+	if selector == nil {
+		return nil, nil
+	}
+	labelSelector := labels.Nothing()
+	if selector.Selector != nil {
+		s, err := v1.LabelSelectorAsSelector(selector.Selector)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid cluster selector: %w", err)
+		}
+		labelSelector = s
+	}
+
+	clusterList := &utilsv1alpha1.RemoteClusterList{}
+	if err := c.List(ctx, clusterList); err != nil {
+		return nil, err
+	}
+
+	var clusters, forbidden []string
+	for _, cluster := range clusterList.Items {
+		if !labelSelector.Matches(labels.Set(cluster.Labels)) && !slices.Contains(selector.Names, cluster.Name) {
+			continue
+		}
+		allowed, err := clusterAllows(ctx, c, cluster, namespace)
+		if err != nil {
+			return nil, err
+		}
+		if allowed {
+			clusters = append(clusters, cluster.Name)
+		} else {
+			forbidden = append(forbidden, cluster.Name)
+		}
+	}
+	for _, name := range selector.Names {
+		if slices.Contains(forbidden, name) {
+			return nil, &replicator.Error{
+				Reason:  reasonClusterForbidden,
+				Message: fmt.Sprintf("RemoteCluster %s doesn't allow replicating from namespace %s", name, namespace),
+			}
+		}
+		if !slices.Contains(clusters, name) {
+			return nil, &replicator.Error{
+				Reason:  reasonClusterNotFound,
+				Message: fmt.Sprintf("Could not find RemoteCluster %s", name),
+			}
+		}
+	}
+	sort.Strings(clusters)
+	return clusters, nil
+}
+
+// clusterAllows reports whether the allowedNamespaces of cluster select
+// namespace.
+func clusterAllows(ctx context.Context, c client.Client, cluster utilsv1alpha1.RemoteCluster, namespace string) (bool, error) {
+	allowed := cluster.Spec.AllowedNamespaces
+	if allowed == nil {
+		return false, nil
+	}
+	if slices.Contains(allowed.Namespaces, namespace) {
+		return true, nil
+	}
+	if allowed.NamespaceSelector == nil {
+		return false, nil
+	}
+	selector, err := v1.LabelSelectorAsSelector(allowed.NamespaceSelector)
+	if err != nil {
+		return false, fmt.Errorf("Invalid allowedNamespaces of RemoteCluster %s: %w", cluster.Name, err)
+	}
+	ns := &corev1.Namespace{}
+	if err := c.Get(ctx, types.NamespacedName{Name: namespace}, ns); err != nil {
+		return false, err
+	}
+	return selector.Matches(labels.Set(ns.Labels)), nil
+}
+
+// clusterError prefixes err with the cluster it happened in. Errors that
+// aren't replication errors mean that the cluster couldn't be reached, they
+// are reported as ClusterUnavailable and retried.
+func clusterError(cluster string, err error) error {
+	var replicationErr *replicator.Error
+	if errors.As(err, &replicationErr) {
+		return fmt.Errorf("cluster %s: %w", cluster, err)
+	}
+	return &replicator.Error{
+		Reason:  reasonClusterUnavailable,
+		Message: fmt.Sprintf("cluster %s: %s", cluster, err),
+	}
+}
+
+// clusterUnavailable reports whether any of errs is ClusterUnavailable,
+// SourceClusterUnavailable or SourceClusterForbidden.
+func clusterUnavailable(errs []error) bool {
+	for _, err := range errs {
+		switch replicator.ReasonFor(err) {
+		case reasonClusterUnavailable, reasonSourceClusterUnavailable, reasonSourceClusterForbidden:
+			return true
+		}
+	}
+	return false
+}
+
+// remoteDestinations returns the statuses of the destinations in remote
+// clusters.
+func remoteDestinations(destinations []utilsv1alpha1.DestinationStatus) []utilsv1alpha1.DestinationStatus {
+	var remote []utilsv1alpha1.DestinationStatus
+	for _, destination := range destinations {
+		if destination.Cluster != "" {
+			remote = append(remote, destination)
+		}
+	}
+	return remote
+}
+
+// replicateRemote copies source to the destination of replication in every
+// cluster selected by rr, and removes the copies from clusters that are no
+// longer selected. It returns the statuses of the remote destinations, the
+// copies that drifted and the existing objects that conflicted with a copy.
+func (r *ReplicatedResourceReconciler) replicateRemote(ctx context.Context, log logr.Logger, rr *utilsv1alpha1.ReplicatedResource, kindReplicator replicator.Replicator, replication *replicator.Replication, source client.Object, now v1.Time) ([]utilsv1alpha1.DestinationStatus, []string, []string, []error) {
+	previous := remoteDestinations(rr.Status.Destinations)
+	if rr.Spec.Destination.Clusters == nil && len(previous) == 0 {
+		return nil, nil, nil, nil
+	}
+	selected, err := selectClusters(ctx, r.Client, rr.Spec.Destination.Clusters, rr.Namespace)
+	if err != nil {
+		return previous, nil, nil, []error{err}
+	}
+
+	remoteReplication := *replication
+	remoteReplication.OwnerReferences = nil
+	remoteReplication.RemoteOwner = replicator.RemoteOwner("ReplicatedResource", rr)
+	gvk := rr.Spec.AllSources()[0].GroupVersionKind()
+	var statuses []utilsv1alpha1.DestinationStatus
+	var drifted, conflicts []string
+	var replicateErrors []error
+	for _, cluster := range selected {
+		destination := fmt.Sprintf("%s/%s", cluster, replication.Destination)
+		remote, err := r.RemoteClusters.Get(ctx, cluster)
+		var result replicator.Result
+		if err == nil {
+			result, err = replicator.ReplicateRemote(ctx, r.Client, remote, log.WithValues("cluster", cluster), kindReplicator, &remoteReplication, source)
+		}
+		if err != nil {
+			err = clusterError(cluster, err)
+			replicateErrors = append(replicateErrors, err)
+		}
+		recordReplication(r.Recorder, rr, destination, rr.Spec.DriftPolicy, result, err)
+		recordReplicationMetrics(gvk.GroupKind().String(), rr.Spec.DriftPolicy, result, err)
+		if result.Drifted {
+			drifted = append(drifted, destination)
+		}
+		if result.Conflict {
+			conflicts = append(conflicts, destination)
+		}
+		statuses = append(statuses, destinationStatus(cluster, rr.Spec.AllSources()[0], replication.Destination, rr.Spec.DriftPolicy, result, err, now, previous))
+	}
+
+	for _, destination := range previous {
+		if slices.Contains(selected, destination.Cluster) {
+			continue
+		}
+		log.Info(fmt.Sprintf("Cluster %s is no longer selected, removing copy", destination.Cluster))
+		if err := r.cleanupRemote(ctx, rr, utilsv1alpha1.DeletionDelete, kindReplicator.NewObject(), destination); err != nil {
+			err = clusterError(destination.Cluster, err)
+			replicateErrors = append(replicateErrors, err)
+			destination.State = utilsv1alpha1.DestinationFailed
+			destination.Message = err.Error()
+			statuses = append(statuses, destination)
+		}
+	}
+
+	return statuses, drifted, conflicts, replicateErrors
+}
+
+// cleanupRemote deletes the destination in a remote cluster, or releases
+// it when policy is Orphan, if it's managed by rr. dest is an empty object
+// of the kind of the destination. Copies in RemoteClusters that no longer
+// exist are left as they are.
+func (r *ReplicatedResourceReconciler) cleanupRemote(ctx context.Context, rr *utilsv1alpha1.ReplicatedResource, policy utilsv1alpha1.DeletionPolicy, dest client.Object, destination utilsv1alpha1.DestinationStatus) error {
+	remote, err := r.RemoteClusters.Get(ctx, destination.Cluster)
+	if kerrors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	key := types.NamespacedName{Namespace: destination.Namespace, Name: destination.Name}
+	if err := remote.Get(ctx, key, dest); err != nil {
+		return client.IgnoreNotFound(err)
+	}
+	if dest.GetAnnotations()[common.RemoteOwnerAnnotation] != replicator.RemoteOwner("ReplicatedResource", rr) {
+		return nil
+	}
+	if policy == utilsv1alpha1.DeletionOrphan {
+		return client.IgnoreNotFound(replicator.Release(ctx, remote, dest, rr))
+	}
+	return client.IgnoreNotFound(remote.Delete(ctx, dest))
+}
+
+// findObjectsForRemoteCluster returns requests for every ReplicatedResource
+// that replicates to remote clusters.
+func (r *ReplicatedResourceReconciler) findObjectsForRemoteCluster(ctx context.Context, obj client.Object) []reconcile.Request {
+	replicatedResources := &utilsv1alpha1.ReplicatedResourceList{}
+	if err := r.List(ctx, replicatedResources); err != nil {
+		return []reconcile.Request{}
+	}
+
+	var requests []reconcile.Request
+	for _, item := range replicatedResources.Items {
+		if item.Spec.Destination.Clusters != nil || len(remoteDestinations(item.Status.Destinations)) > 0 {
+			requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&item)})
+		}
+	}
+	return requests
+}
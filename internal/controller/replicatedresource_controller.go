@@ -24,20 +24,28 @@ import (
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
 
-	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/client-go/util/flowcontrol"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	utilsv1alpha1 "github.com/russell/resource-replication-operator/api/v1alpha1"
+	"github.com/russell/resource-replication-operator/internal/accessreview"
+	"github.com/russell/resource-replication-operator/internal/remotecluster"
 	"github.com/russell/resource-replication-operator/replicator"
 )
 
@@ -46,18 +54,49 @@ type ReplicatedResourceReconciler struct {
 	client.Client
 	Log    logr.Logger
 	Scheme *runtime.Scheme
-}
+	// Replicators holds the kinds with a dedicated replicator, defaults
+	// to replicator.NewRegistry. Other kinds are replicated generically.
+	Replicators *replicator.Registry
+	// Recorder records events, defaults to the manager's recorder.
+	Recorder record.EventRecorder
+	// PolicyDefaultDeny denies replicating sources that aren't selected by
+	// any ReplicationPolicy rule.
+	PolicyDefaultDeny bool
+	// AccessReviewInterval is how often the user recorded by the admission
+	// webhook is checked to still be able to get the sources. Without the
+	// webhook no user is recorded and nothing is reviewed.
+	AccessReviewInterval time.Duration
+	// ResyncInterval is how often ReplicatedResources are replicated again
+	// when nothing changes, defaults to 30 minutes.
+	ResyncInterval time.Duration
+	// CompatibilityAnnotations accepts the Reflector annotations of sources
+	// as consent to their replication.
+	CompatibilityAnnotations bool
+	// RolloutInterval is the minimum time between two workload restarts,
+	// across every ReplicatedResource, defaults to 10 seconds.
+	RolloutInterval time.Duration
+	// RemoteClusters builds the clients of the clusters destinations are
+	// replicated to, defaults to reading RemoteClusters with the manager's
+	// client.
+	RemoteClusters *remotecluster.Clients
 
-const (
-	nameField      = ".spec.source.name"
-	namespaceField = ".spec.source.namespace"
-	kindField      = ".spec.source.kind"
-)
+	kinds *kindWatches
+	// remoteSources watches the sources read from other clusters.
+	remoteSources *remotecluster.Sources
+	// rolloutLimiter spaces the workload restarts by RolloutInterval.
+	rolloutLimiter flowcontrol.RateLimiter
+}
 
 // +kubebuilder:rbac:groups=utils.simopolis.xyz,resources=replicatedresources,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups="",resources=secrets;configmaps,verbs=get;list;watch;create;update;patch;delete
 // +kubebuilder:rbac:groups=utils.simopolis.xyz,resources=replicatedresources/status,verbs=get;update;patch
 // +kubebuilder:rbac:groups=utils.simopolis.xyz,resources=replicatedresources/finalizers,verbs=update
+// +kubebuilder:rbac:groups="",resources=namespaces,verbs=get;list;watch
+// +kubebuilder:rbac:groups="",resources=events,verbs=create;patch
+// +kubebuilder:rbac:groups=utils.simopolis.xyz,resources=replicationpolicies,verbs=get;list;watch
+// +kubebuilder:rbac:groups=authorization.k8s.io,resources=subjectaccessreviews,verbs=create
+// +kubebuilder:rbac:groups=utils.simopolis.xyz,resources=remoteclusters,verbs=get;list;watch
+// +kubebuilder:rbac:groups=apps,resources=deployments;statefulsets;daemonsets,verbs=get;list;watch;patch
 func (r *ReplicatedResourceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	log := r.Log.WithValues("replicatedresource", req.NamespacedName)
 
@@ -67,89 +106,314 @@ func (r *ReplicatedResourceReconciler) Reconcile(ctx context.Context, req ctrl.R
 			return ctrl.Result{}, err
 		} else {
 			log.Info("Could not find ReplicatedResource. Ignoring since object must be deleted.")
+			forgetOwnerMetrics("ReplicatedResource", req.Namespace, req.Name)
+			r.remoteSources.Forget(req.NamespacedName, nil)
 			return ctrl.Result{}, nil
 		}
 	}
 	log.Info("Started Processing")
 
-	sourceNamespacedName := types.NamespacedName{Namespace: rr.Spec.Source.Namespace, Name: rr.Spec.Source.Name}
-	sourceKind := rr.Spec.Source.Kind
+	if !rr.DeletionTimestamp.IsZero() {
+		return ctrl.Result{}, r.finalize(ctx, rr)
+	}
+	if controllerutil.AddFinalizer(rr, cleanupFinalizer) {
+		if err := r.Update(ctx, rr); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	sources := rr.Spec.AllSources()
+	sourceNamespacedName := types.NamespacedName{Namespace: sources[0].Namespace, Name: sources[0].Name}
+	destNamespacedName := types.NamespacedName{Namespace: rr.Namespace, Name: rr.DestinationName()}
+	sourceGVK := sources[0].GroupVersionKind()
 	var replicateError error = nil
-	requeueAfter := time.Duration(0)
+	now := v1.Now()
+	requeueAfter := r.ResyncInterval
+	if _, ok := accessreview.RecordedUser(rr); ok && r.AccessReviewInterval > 0 && r.AccessReviewInterval < requeueAfter {
+		requeueAfter = r.AccessReviewInterval
+	}
 
-	if req.NamespacedName == sourceNamespacedName {
-		log.Info("Can't replicate when the source matches the source")
-		return ctrl.Result{}, nil
+	// Sources in other clusters can't have events
+	recorder := r.Recorder
+	if len(remoteSources(rr)) > 0 {
+		recorder = ownerRecorder{EventRecorder: r.Recorder, owner: rr}
+	}
+
+	selfReplicating := false
+	for _, source := range sources {
+		if source.ClusterRef == nil && source.GroupVersionKind() == sourceGVK && (types.NamespacedName{Namespace: source.Namespace, Name: source.Name}) == destNamespacedName {
+			selfReplicating = true
+		}
 	}
-	var op controllerutil.OperationResult
-	if sourceKind == "Secret" {
-		sr := replicator.SecretReplicator{Client: r.Client, Log: r.Log, Scheme: r.Scheme}
-		operation, _, err := sr.ReplicateSecret(ctx, rr)
-		op = operation
+	var result replicator.Result
+	remoteStatuses := remoteDestinations(rr.Status.Destinations)
+	var remoteDrifted, remoteConflicts []string
+	var remoteErrors []error
+	kindReplicator, err := r.kinds.ReplicatorFor(sourceGVK)
+	if len(rr.Spec.Sources) > 0 && rr.Spec.Source.Name != "" {
+		replicateError = fmt.Errorf("Only one of source and sources can be set")
+	} else if selfReplicating {
+		replicateError = &replicator.Error{
+			Reason:  "SelfReplication",
+			Message: fmt.Sprintf("Can't replicate %s onto itself", destNamespacedName),
+		}
+	} else if err != nil {
+		replicateError = err
+	} else if err := r.authorize(ctx, rr, sources, destNamespacedName.Namespace); err != nil {
+		replicateError = err
+	} else if source, fetched, err := r.fetchSources(ctx, log, rr, kindReplicator, sourceNamespacedName); err != nil {
 		replicateError = err
 	} else {
-		replicateError = fmt.Errorf("Unsupported kind %s", sourceKind)
+		replication := &replicator.Replication{
+			Source:      sourceNamespacedName,
+			Destination: destNamespacedName,
+			OwnerReferences: []v1.OwnerReference{
+				*v1.NewControllerRef(rr, utilsv1alpha1.GroupVersion.WithKind("ReplicatedResource")),
+			},
+			Fields:                   rr.Spec.Fields,
+			Labels:                   rr.Spec.Destination.Labels,
+			Annotations:              rr.Spec.Destination.Annotations,
+			SourceMetadata:           rr.Spec.Destination.SourceMetadata,
+			DriftPolicy:              rr.Spec.DriftPolicy,
+			Transform:                rr.Spec.Transform,
+			ConflictPolicy:           rr.Spec.ConflictPolicy,
+			CompatibilityAnnotations: r.CompatibilityAnnotations,
+		}
+		result, replicateError = replicator.ReplicateFrom(ctx, r.Client, log, kindReplicator, replication, source)
+		result.Sources = fetched
+		// Remote copies only depend on the local one being authorized
+		if replicateError == nil || result.Conflict {
+			remoteStatuses, remoteDrifted, remoteConflicts, remoteErrors = r.replicateRemote(ctx, log, rr, kindReplicator, replication, source, now)
+		}
+	}
+	op := result.Operation
+	r.remoteSources.Forget(req.NamespacedName, remoteSources(rr))
+
+	rr.Status.SourceNotFoundSince = trackSourceNotFound(rr.Status.SourceNotFoundSince, errors.IsNotFound(replicateError), now)
+	removed := false
+	if remove, after := sourceDeletionDue(rr.Spec.OnSourceDeleted, rr.Spec.SourceDeletedGracePeriod, rr.Status.SourceNotFoundSince, now.Time); remove {
+		if err := cleanupDestination(ctx, r.Client, rr, utilsv1alpha1.DeletionDelete, kindReplicator.NewObject(), destNamespacedName); err != nil {
+			return ctrl.Result{}, err
+		}
+		var kept []utilsv1alpha1.DestinationStatus
+		for _, destination := range remoteStatuses {
+			if err := r.cleanupRemote(ctx, rr, utilsv1alpha1.DeletionDelete, kindReplicator.NewObject(), destination); err != nil {
+				remoteErrors = append(remoteErrors, clusterError(destination.Cluster, err))
+				kept = append(kept, destination)
+				continue
+			}
+			if destination.UID != "" {
+				r.Recorder.Eventf(rr, corev1.EventTypeNormal, eventDestinationDeleted, "Deleted %s as the source was deleted", describeDestination(destination))
+			}
+		}
+		remoteStatuses = kept
+		removed = true
+	} else if after > 0 && (requeueAfter == 0 || after < requeueAfter) {
+		requeueAfter = after
 	}
 
+	recordReplication(recorder, rr, destNamespacedName.String(), rr.Spec.DriftPolicy, result, replicateError)
+	recordReplicationMetrics(sourceGVK.GroupKind().String(), rr.Spec.DriftPolicy, result, replicateError)
+	localError := replicateError
+	if len(remoteErrors) > 0 {
+		replicateError = utilerrors.NewAggregate(append([]error{replicateError}, remoteErrors...))
+	}
+	message := "Successfully Replicated"
 	if replicateError != nil {
+		recordForbidden(recorder, rr, replicateError)
 		rr.Status.Phase = "Failed"
-		rr.Status.Conditions = []utilsv1alpha1.ReplicatedResourceCondition{{
-			Type:               utilsv1alpha1.ReplicatedResourceComplete,
-			Status:             corev1.ConditionTrue,
-			LastProbeTime:      v1.Now(),
-			LastTransitionTime: v1.Now(),
-			Reason:             "Error",
-			Message:            replicateError.Error(),
-		}}
-
-	} else if op == controllerutil.OperationResultCreated || op == controllerutil.OperationResultUpdated || op == controllerutil.OperationResultNone {
+	} else {
 		rr.Status.Phase = "Completed"
-		var message string
 		if op == controllerutil.OperationResultNone {
 			message = "Resource already up-to-date"
-		} else {
-			message = "Successfully Replicated"
 		}
-		rr.Status.Conditions = []utilsv1alpha1.ReplicatedResourceCondition{{
-			Type:               utilsv1alpha1.ReplicatedResourceComplete,
-			Status:             corev1.ConditionTrue,
-			LastProbeTime:      v1.Now(),
-			LastTransitionTime: v1.Now(),
-			Reason:             "Replicated",
-			Message:            message,
-		}}
 	}
+	setConditions(&rr.Status.Conditions, rr.Generation, replicateError, message)
+	var drifted []string
+	if result.Drifted {
+		drifted = append(drifted, destNamespacedName.String())
+	}
+	drifted = append(drifted, remoteDrifted...)
+	setDriftCondition(&rr.Status.Conditions, rr.Generation, rr.Spec.DriftPolicy, drifted)
+	var conflicts []string
+	if result.Conflict {
+		conflicts = append(conflicts, destNamespacedName.String())
+	}
+	conflicts = append(conflicts, remoteConflicts...)
+	setConflictCondition(&rr.Status.Conditions, rr.Generation, conflicts)
+	rr.Status.ObservedGeneration = rr.Generation
+
+	rr.Status.LastSyncTime = &now
+	if removed {
+		for _, destination := range rr.Status.Destinations {
+			if destination.Cluster == "" && destination.UID != "" {
+				r.Recorder.Eventf(rr, corev1.EventTypeNormal, eventDestinationDeleted, "Deleted %s as the source was deleted", describeDestination(destination))
+			}
+		}
+		rr.Status.Destinations = remoteStatuses
+	} else {
+		rr.Status.Destinations = append([]utilsv1alpha1.DestinationStatus{
+			destinationStatus("", sources[0], destNamespacedName, rr.Spec.DriftPolicy, result, localError, now, rr.Status.Destinations),
+		}, remoteStatuses...)
+	}
+	if replicateError == nil {
+		rr.Status.Sources = sourceRevisions(sources, result)
+		rr.Status.ContentHash = result.Hash
+		rr.Status.LastSuccessfulSyncTime = &now
+	}
+	var rolloutError error
+	if rr.Spec.Rollout == nil {
+		rr.Status.Rollout = nil
+	} else if localError == nil && !removed {
+		after, err := r.rollout(ctx, log, rr, sourceGVK.Kind, result.Hash, now)
+		if err != nil {
+			log.Info(fmt.Sprintf("Error restarting workloads: %s", err))
+			rolloutError = err
+		} else if after > 0 && (requeueAfter == 0 || after < requeueAfter) {
+			requeueAfter = after
+		}
+	}
+	destinations := 0
+	for _, destination := range rr.Status.Destinations {
+		if destination.UID != "" {
+			destinations++
+		}
+	}
+	recordOwnerMetrics("ReplicatedResource", rr, destinations, replicateError == nil)
 
 	if err := r.Status().Update(ctx, rr); err != nil {
 		log.Info(fmt.Sprintf("Error updating ReplicatedResource: %s", err))
 		return ctrl.Result{RequeueAfter: requeueAfter}, err
 	}
+	if errs := append([]error{localError}, remoteErrors...); clusterUnavailable(errs) {
+		// Retry unreachable clusters with backoff
+		return ctrl.Result{}, utilerrors.NewAggregate(errs)
+	}
+	if rolloutError != nil {
+		// Retry the pending restarts with backoff
+		return ctrl.Result{}, rolloutError
+	}
 
 	log.Info("Successfully Replicated")
 
-	return ctrl.Result{}, nil
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// finalize deletes or releases the destinations of rr, according to its
+// deletion policy, and removes the finalizer. The destinations are those
+// listed in the status and the current destination.
+func (r *ReplicatedResourceReconciler) finalize(ctx context.Context, rr *utilsv1alpha1.ReplicatedResource) error {
+	if !controllerutil.ContainsFinalizer(rr, cleanupFinalizer) {
+		return nil
+	}
+
+	apiVersion, kind := rr.Spec.AllSources()[0].GroupVersionKind().ToAPIVersionAndKind()
+	destinations := append([]utilsv1alpha1.DestinationStatus{{
+		APIVersion: apiVersion,
+		Kind:       kind,
+		Namespace:  rr.Namespace,
+		Name:       rr.DestinationName(),
+	}}, rr.Status.Destinations...)
+	for _, destination := range destinations {
+		kindReplicator, err := r.kinds.ReplicatorFor(schema.FromAPIVersionAndKind(destination.APIVersion, destination.Kind))
+		if meta.IsNoMatchError(err) {
+			// The kind was uninstalled along with its objects
+			continue
+		} else if err != nil {
+			return err
+		}
+		if destination.Cluster != "" {
+			if err := r.cleanupRemote(ctx, rr, rr.Spec.DeletionPolicy, kindReplicator.NewObject(), destination); err != nil {
+				return err
+			}
+			continue
+		}
+		key := types.NamespacedName{Namespace: destination.Namespace, Name: destination.Name}
+		if err := cleanupDestination(ctx, r.Client, rr, rr.Spec.DeletionPolicy, kindReplicator.NewObject(), key); err != nil {
+			return err
+		}
+	}
+
+	forgetOwnerMetrics("ReplicatedResource", rr.Namespace, rr.Name)
+	r.remoteSources.Forget(client.ObjectKeyFromObject(rr), nil)
+	controllerutil.RemoveFinalizer(rr, cleanupFinalizer)
+	return r.Update(ctx, rr)
 }
 
-func (r *ReplicatedResourceReconciler) findObjectsForSecret(ctx context.Context, obj client.Object) []reconcile.Request {
-	return r.findObjectsForReplicatedResource(obj, "Secret")
+// authorize checks that the user recorded by the admission webhook can
+// still get the sources of rr, and that ReplicationPolicies allow them to
+// be replicated to destNamespace.
+func (r *ReplicatedResourceReconciler) authorize(ctx context.Context, rr *utilsv1alpha1.ReplicatedResource, sources []utilsv1alpha1.ReplicatedResourceSource, destNamespace string) error {
+	if user, ok := accessreview.RecordedUser(rr); ok {
+		if err := accessreview.Review(ctx, r.Client, r.RESTMapper(), user, rr); err != nil {
+			return err
+		}
+	}
+	policies := policyChecker{Reader: r.Client, defaultDeny: r.PolicyDefaultDeny}
+	return policies.check(ctx, sources, policyReplication{
+		destNamespace: destNamespace,
+		transforms:    usedTransforms(rr.Spec.Transform),
+		fanOutRank:    ownerRank(rr.UID),
+	})
 }
 
+// fetchSources reads the source of rr, key, or merges the data of every
+// source of rr into an object of the kind of the first source. It also
+// returns the objects that were read.
+func (r *ReplicatedResourceReconciler) fetchSources(ctx context.Context, log logr.Logger, rr *utilsv1alpha1.ReplicatedResource, kindReplicator replicator.Replicator, key types.NamespacedName) (client.Object, []client.Object, error) {
+	if len(rr.Spec.Sources) == 0 {
+		sourceClient, err := r.sourceClient(ctx, rr, rr.Spec.Source, kindReplicator)
+		if err != nil {
+			return nil, nil, err
+		}
+		source, err := kindReplicator.Fetch(ctx, sourceClient, key)
+		if err != nil {
+			log.Info(fmt.Sprintf("Error reading source: %s", err), "source", key.String())
+			return nil, nil, err
+		}
+		return source, []client.Object{source}, nil
+	}
+
+	sources := make([]replicator.MergeSource, 0, len(rr.Spec.Sources))
+	for _, source := range rr.Spec.Sources {
+		sourceReplicator, err := r.kinds.ReplicatorFor(source.GroupVersionKind())
+		if err != nil {
+			return nil, nil, err
+		}
+		sourceClient, err := r.sourceClient(ctx, rr, source.ReplicatedResourceSource, sourceReplicator)
+		if err != nil {
+			return nil, nil, err
+		}
+		sources = append(sources, replicator.MergeSource{
+			Replicator:               sourceReplicator,
+			Key:                      types.NamespacedName{Namespace: source.Namespace, Name: source.Name},
+			KeyPrefix:                source.KeyPrefix,
+			Client:                   sourceClient,
+			CompatibilityAnnotations: r.CompatibilityAnnotations,
+		})
+	}
+	return replicator.Merge(ctx, r.Client, rr.Namespace, sources, rr.Spec.KeyConflictPolicy)
+}
+
+// findObjectsForKind returns a map function for source objects of the
+// group qualified kind objKind, e.g. Secret or NetworkPolicy.networking.k8s.io.
+func (r *ReplicatedResourceReconciler) findObjectsForKind(objKind string) handler.MapFunc {
+	return func(ctx context.Context, obj client.Object) []reconcile.Request {
+		return r.findObjectsForReplicatedResource(obj, objKind)
+	}
+}
+
+// findObjectsForReplicatedResource returns requests for every
+// ReplicatedResource whose source is obj, objKind is the group qualified
+// kind of obj, e.g. Secret or NetworkPolicy.networking.k8s.io.
 func (r *ReplicatedResourceReconciler) findObjectsForReplicatedResource(obj client.Object, objKind string) []reconcile.Request {
 	attachedReplicatedResource := &utilsv1alpha1.ReplicatedResourceList{}
 
-	objName := obj.GetName()
-	objNamespace := obj.GetNamespace()
-
-	r.Log.Info(fmt.Sprintf("Dependent %s/%s of kind %s updated triggering a refresh", objNamespace, objName, objKind))
+	r.Log.Info(fmt.Sprintf("Dependent %s/%s of kind %s updated triggering a refresh", obj.GetNamespace(), obj.GetName(), objKind))
 
 	// Filter the list of replicated resources by the ones that
 	// target this object by name and namespace
-	listOps := &client.ListOptions{
-		FieldSelector: fields.AndSelectors(
-			fields.OneTermEqualSelector(kindField, objKind),
-			fields.OneTermEqualSelector(nameField, objName),
-			fields.OneTermEqualSelector(namespaceField, objNamespace)),
-	}
+	listOps := sourceListOptions(obj, objKind)
 	err := r.List(context.TODO(), attachedReplicatedResource, listOps)
 	if err != nil {
 		return []reconcile.Request{}
@@ -167,50 +431,77 @@ func (r *ReplicatedResourceReconciler) findObjectsForReplicatedResource(obj clie
 	return requests
 }
 
+// findObjectsForReplicationPolicy returns requests for every
+// ReplicatedResource, as any of them can be affected by a policy change.
+func (r *ReplicatedResourceReconciler) findObjectsForReplicationPolicy(ctx context.Context, obj client.Object) []reconcile.Request {
+	replicatedResources := &utilsv1alpha1.ReplicatedResourceList{}
+	if err := r.List(ctx, replicatedResources); err != nil {
+		return []reconcile.Request{}
+	}
+
+	requests := make([]reconcile.Request, len(replicatedResources.Items))
+	for i, item := range replicatedResources.Items {
+		requests[i] = reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&item)}
+	}
+	return requests
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *ReplicatedResourceReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &utilsv1alpha1.ReplicatedResource{}, nameField, func(rawObj client.Object) []string {
-		// Extract the name from the ReplicatedResource Spec, if one is provided
-		replicatedResource := rawObj.(*utilsv1alpha1.ReplicatedResource)
-		if replicatedResource.Spec.Source.Name == "" {
-			return nil
-		}
-		return []string{replicatedResource.Spec.Source.Name}
+	if err := indexSources(mgr, &utilsv1alpha1.ReplicatedResource{}, func(rawObj client.Object) []utilsv1alpha1.ReplicatedResourceSource {
+		return rawObj.(*utilsv1alpha1.ReplicatedResource).Spec.AllSources()
 	}); err != nil {
 		return err
 	}
 
-	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &utilsv1alpha1.ReplicatedResource{}, namespaceField, func(rawObj client.Object) []string {
-		// Extract the namespace from the ReplicatedResource Spec, if one is provided
-		replicatedResource := rawObj.(*utilsv1alpha1.ReplicatedResource)
-		if replicatedResource.Spec.Source.Namespace == "" {
-			return nil
-		}
-		return []string{replicatedResource.Spec.Source.Namespace}
-	}); err != nil {
-		return err
+	if r.Replicators == nil {
+		r.Replicators = replicator.NewRegistry(r.Log)
 	}
-
-	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &utilsv1alpha1.ReplicatedResource{}, kindField, func(rawObj client.Object) []string {
-		// Extract the namespace from the ReplicatedResource Spec, if one is provided
-		replicatedResource := rawObj.(*utilsv1alpha1.ReplicatedResource)
-		if replicatedResource.Spec.Source.Kind == "" {
-			return nil
-		}
-		return []string{replicatedResource.Spec.Source.Kind}
-	}); err != nil {
+	if r.Recorder == nil {
+		r.Recorder = mgr.GetEventRecorderFor("replicatedresource-controller")
+	}
+	if r.ResyncInterval == 0 {
+		r.ResyncInterval = defaultResyncInterval
+	}
+	if r.RolloutInterval == 0 {
+		r.RolloutInterval = 10 * time.Second
+	}
+	r.rolloutLimiter = flowcontrol.NewTokenBucketRateLimiter(float32(1/r.RolloutInterval.Seconds()), 1)
+	if r.RemoteClusters == nil {
+		r.RemoteClusters = &remotecluster.Clients{Client: mgr.GetClient(), Scheme: mgr.GetScheme()}
+	}
+	r.remoteSources = &remotecluster.Sources{
+		Client: mgr.GetClient(),
+		Scheme: mgr.GetScheme(),
+		Events: make(chan event.TypedGenericEvent[remotecluster.Source]),
+	}
+	if err := mgr.Add(r.remoteSources); err != nil {
 		return err
 	}
-
-	return ctrl.NewControllerManagedBy(mgr).
+	r.kinds = &kindWatches{
+		Replicators:        r.Replicators,
+		Log:                r.Log,
+		Owner:              &utilsv1alpha1.ReplicatedResource{},
+		FindObjectsForKind: r.findObjectsForKind,
+	}
+	c, err := r.kinds.Setup(mgr, ctrl.NewControllerManagedBy(mgr).
 		Named("ReplicatedResource").
-		For(&utilsv1alpha1.ReplicatedResource{}).
-		Owns(&corev1.ConfigMap{}).
-		Owns(&corev1.Secret{}).
+		// The status is updated on every reconcile
+		For(&utilsv1alpha1.ReplicatedResource{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
 		Watches(
-			&corev1.Secret{},
-			handler.EnqueueRequestsFromMapFunc(r.findObjectsForSecret),
-			builder.WithPredicates(predicate.ResourceVersionChangedPredicate{}),
+			&utilsv1alpha1.ReplicationPolicy{},
+			handler.EnqueueRequestsFromMapFunc(r.findObjectsForReplicationPolicy),
 		).
-		Complete(r)
+		Watches(
+			&utilsv1alpha1.RemoteCluster{},
+			handler.EnqueueRequestsFromMapFunc(r.findObjectsForRemoteCluster),
+		).
+		WatchesRawSource(source.Channel(r.remoteSources.Events,
+			handler.TypedEnqueueRequestsFromMapFunc(r.findObjectsForRemoteSource)))).
+		Build(r)
+	if err != nil {
+		return err
+	}
+	r.kinds.Started(c)
+	return nil
 }
@@ -19,17 +19,27 @@ package controller
 import (
 	"bytes"
 	"context"
+	"strings"
 	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 
 	utilsv1alpha1 "github.com/russell/resource-replication-operator/api/v1alpha1"
+	"github.com/russell/resource-replication-operator/replicator/common"
 )
 
 var _ = Describe("CronJob controller", func() {
@@ -40,6 +50,12 @@ var _ = Describe("CronJob controller", func() {
 		ReplicatedResourceNamespace = "default"
 		SecretName                  = "test-secret"
 		SecretNamespace             = "default"
+		ConfigMapName               = "test-configmap"
+		ReplicatedConfigMapName     = "test-replicated-configmap"
+		RoleName                    = "test-role"
+		LabelledSecretName          = "test-labelled-secret"
+		DestinationName             = "test-destination"
+		ReplicatedRoleName          = "test-replicated-role"
 
 		timeout  = time.Second * 10
 		duration = time.Second * 10
@@ -163,5 +179,1319 @@ var _ = Describe("CronJob controller", func() {
 				return false
 			}, timeout, interval).Should(BeTrue())
 		})
+
+		It("Should replicate a configmap", func() {
+			By("By creating a new ConfigMap")
+			ctx := context.Background()
+			configMap := &corev1.ConfigMap{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "v1",
+					Kind:       "ConfigMap",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      ConfigMapName,
+					Namespace: SecretNamespace,
+				},
+				Data: map[string]string{
+					"test": "this is a test.",
+				},
+				BinaryData: map[string][]byte{
+					"binary": {0x00, 0x01, 0x02},
+				},
+			}
+			Expect(k8sClient.Create(ctx, configMap)).Should(Succeed())
+
+			By("By creating a new ReplicatedResource")
+			replicatedResource := &utilsv1alpha1.ReplicatedResource{
+				TypeMeta: metav1.TypeMeta{
+					APIVersion: "utils.simopolis.xyz/v1alpha1",
+					Kind:       "ReplicatedResource",
+				},
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      ReplicatedConfigMapName,
+					Namespace: ReplicatedResourceNamespace,
+				},
+				Spec: utilsv1alpha1.ReplicatedResourceSpec{
+					Source: utilsv1alpha1.ReplicatedResourceSource{
+						Namespace: SecretNamespace,
+						Name:      ConfigMapName,
+						Kind:      "ConfigMap",
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, replicatedResource)).Should(Succeed())
+
+			replicatedConfigMapLookupKey := types.NamespacedName{Name: ReplicatedConfigMapName, Namespace: ReplicatedResourceNamespace}
+			replicatedConfigMap := &corev1.ConfigMap{}
+			Eventually(func() error {
+				return k8sClient.Get(ctx, replicatedConfigMapLookupKey, replicatedConfigMap)
+			}, timeout, interval).Should(Succeed())
+			Expect(replicatedConfigMap.Data["test"]).Should(Equal("this is a test."))
+			Expect(replicatedConfigMap.BinaryData["binary"]).Should(Equal([]byte{0x00, 0x01, 0x02}))
+
+			By("By updating the configmap")
+			configMapLookupKey := types.NamespacedName{Name: ConfigMapName, Namespace: SecretNamespace}
+			Expect(k8sClient.Get(ctx, configMapLookupKey, configMap)).Should(Succeed())
+			configMap.Data["test"] = "this is another test."
+			Expect(k8sClient.Update(ctx, configMap)).Should(Succeed())
+
+			// Wait for the replicated configmap to have the right value
+			Eventually(func() string {
+				if err := k8sClient.Get(ctx, replicatedConfigMapLookupKey, replicatedConfigMap); err != nil {
+					return ""
+				}
+				return replicatedConfigMap.Data["test"]
+			}, timeout, interval).Should(Equal("this is another test."))
+		})
+
+		It("Should replicate a kind without a dedicated replicator", func() {
+			By("By creating a new Role")
+			ctx := context.Background()
+			role := &rbacv1.Role{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      RoleName,
+					Namespace: SecretNamespace,
+				},
+				Rules: []rbacv1.PolicyRule{{
+					APIGroups: []string{""},
+					Resources: []string{"configmaps"},
+					Verbs:     []string{"get"},
+				}},
+			}
+			Expect(k8sClient.Create(ctx, role)).Should(Succeed())
+
+			By("By creating a new ReplicatedResource")
+			replicatedResource := &utilsv1alpha1.ReplicatedResource{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      ReplicatedRoleName,
+					Namespace: ReplicatedResourceNamespace,
+				},
+				Spec: utilsv1alpha1.ReplicatedResourceSpec{
+					Source: utilsv1alpha1.ReplicatedResourceSource{
+						Namespace:  SecretNamespace,
+						Name:       RoleName,
+						APIVersion: "rbac.authorization.k8s.io/v1",
+						Kind:       "Role",
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, replicatedResource)).Should(Succeed())
+
+			replicatedRoleLookupKey := types.NamespacedName{Name: ReplicatedRoleName, Namespace: ReplicatedResourceNamespace}
+			replicatedRole := &rbacv1.Role{}
+			Eventually(func() error {
+				return k8sClient.Get(ctx, replicatedRoleLookupKey, replicatedRole)
+			}, timeout, interval).Should(Succeed())
+			Expect(replicatedRole.Rules).Should(Equal(role.Rules))
+			Expect(replicatedRole.OwnerReferences).Should(HaveLen(1))
+			Expect(replicatedRole.OwnerReferences[0].Name).Should(Equal(ReplicatedRoleName))
+
+			By("By updating the role")
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: RoleName, Namespace: SecretNamespace}, role)).Should(Succeed())
+			role.Rules[0].Verbs = []string{"get", "list"}
+			Expect(k8sClient.Update(ctx, role)).Should(Succeed())
+
+			Eventually(func() []string {
+				if err := k8sClient.Get(ctx, replicatedRoleLookupKey, replicatedRole); err != nil {
+					return nil
+				}
+				return replicatedRole.Rules[0].Verbs
+			}, timeout, interval).Should(Equal([]string{"get", "list"}))
+		})
+
+		It("Should name and label the destination", func() {
+			By("By creating a new labelled Secret")
+			ctx := context.Background()
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      LabelledSecretName,
+					Namespace: SecretNamespace,
+					Labels: map[string]string{
+						"ingress.example.com/tls": "true",
+						"team":                    "payments",
+					},
+				},
+				Data: map[string][]byte{
+					"test": []byte("this is a test."),
+				},
+			}
+			Expect(k8sClient.Create(ctx, secret)).Should(Succeed())
+
+			By("By creating a new ReplicatedResource")
+			replicatedResource := &utilsv1alpha1.ReplicatedResource{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-replicated-labelled-secret",
+					Namespace: ReplicatedResourceNamespace,
+				},
+				Spec: utilsv1alpha1.ReplicatedResourceSpec{
+					Source: utilsv1alpha1.ReplicatedResourceSource{
+						Namespace: SecretNamespace,
+						Name:      LabelledSecretName,
+						Kind:      "Secret",
+					},
+					Destination: utilsv1alpha1.ReplicatedResourceDestination{
+						Name:        DestinationName,
+						Labels:      map[string]string{"mesh.example.com/inject": "true"},
+						Annotations: map[string]string{"owner": "platform"},
+						SourceMetadata: utilsv1alpha1.SourceMetadata{
+							Policy:   utilsv1alpha1.SourceMetadataAllowList,
+							Prefixes: []string{"ingress.example.com/"},
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, replicatedResource)).Should(Succeed())
+
+			destinationLookupKey := types.NamespacedName{Name: DestinationName, Namespace: ReplicatedResourceNamespace}
+			destination := &corev1.Secret{}
+			Eventually(func() error {
+				return k8sClient.Get(ctx, destinationLookupKey, destination)
+			}, timeout, interval).Should(Succeed())
+			Expect(destination.Labels).Should(Equal(map[string]string{
+				"ingress.example.com/tls": "true",
+				"mesh.example.com/inject": "true",
+			}))
+			Expect(destination.Annotations).Should(HaveKeyWithValue("owner", "platform"))
+
+			By("By no longer copying the source labels")
+			Eventually(func() error {
+				if err := k8sClient.Get(ctx, types.NamespacedName{Name: "test-replicated-labelled-secret", Namespace: ReplicatedResourceNamespace}, replicatedResource); err != nil {
+					return err
+				}
+				replicatedResource.Spec.Destination.SourceMetadata = utilsv1alpha1.SourceMetadata{Policy: utilsv1alpha1.SourceMetadataNone}
+				return k8sClient.Update(ctx, replicatedResource)
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func() map[string]string {
+				if err := k8sClient.Get(ctx, destinationLookupKey, destination); err != nil {
+					return nil
+				}
+				return destination.Labels
+			}, timeout, interval).Should(Equal(map[string]string{"mesh.example.com/inject": "true"}))
+		})
+
+		It("Should correct drift on the destination", func() {
+			ctx := context.Background()
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-drift-secret",
+					Namespace: SecretNamespace,
+				},
+				Data: map[string][]byte{
+					"test": []byte("this is a test."),
+				},
+			}
+			Expect(k8sClient.Create(ctx, secret)).Should(Succeed())
+
+			replicatedResource := &utilsv1alpha1.ReplicatedResource{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-replicated-drift-secret",
+					Namespace: ReplicatedResourceNamespace,
+				},
+				Spec: utilsv1alpha1.ReplicatedResourceSpec{
+					Source: utilsv1alpha1.ReplicatedResourceSource{
+						Namespace: SecretNamespace,
+						Name:      secret.Name,
+						Kind:      "Secret",
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, replicatedResource)).Should(Succeed())
+
+			destinationLookupKey := types.NamespacedName{Name: replicatedResource.Name, Namespace: ReplicatedResourceNamespace}
+			destination := &corev1.Secret{}
+			Eventually(func() error {
+				return k8sClient.Get(ctx, destinationLookupKey, destination)
+			}, timeout, interval).Should(Succeed())
+
+			By("By modifying the destination")
+			tamper := func() error {
+				if err := k8sClient.Get(ctx, destinationLookupKey, destination); err != nil {
+					return err
+				}
+				destination.Data["test"] = []byte("tampered")
+				return k8sClient.Update(ctx, destination)
+			}
+			Eventually(tamper, timeout, interval).Should(Succeed())
+
+			Eventually(func() []byte {
+				if err := k8sClient.Get(ctx, destinationLookupKey, destination); err != nil {
+					return nil
+				}
+				return destination.Data["test"]
+			}, timeout, interval).Should(Equal([]byte("this is a test.")))
+
+			Eventually(func() string {
+				if err := k8sClient.Get(ctx, destinationLookupKey, replicatedResource); err != nil {
+					return ""
+				}
+				for _, condition := range replicatedResource.Status.Conditions {
+					if condition.Type == utilsv1alpha1.ReplicatedResourceDrifted {
+						return condition.Reason
+					}
+				}
+				return ""
+			}, timeout, interval).Should(Equal("DriftCorrected"))
+
+			By("By only reporting drift")
+			Eventually(func() error {
+				if err := k8sClient.Get(ctx, destinationLookupKey, replicatedResource); err != nil {
+					return err
+				}
+				replicatedResource.Spec.DriftPolicy = utilsv1alpha1.DriftReportOnly
+				return k8sClient.Update(ctx, replicatedResource)
+			}, timeout, interval).Should(Succeed())
+			Eventually(tamper, timeout, interval).Should(Succeed())
+
+			Eventually(func() metav1.ConditionStatus {
+				if err := k8sClient.Get(ctx, destinationLookupKey, replicatedResource); err != nil {
+					return ""
+				}
+				for _, condition := range replicatedResource.Status.Conditions {
+					if condition.Type == utilsv1alpha1.ReplicatedResourceDrifted {
+						return condition.Status
+					}
+				}
+				return ""
+			}, timeout, interval).Should(Equal(metav1.ConditionTrue))
+			Expect(k8sClient.Get(ctx, destinationLookupKey, destination)).Should(Succeed())
+			Expect(destination.Data["test"]).Should(Equal([]byte("tampered")))
+		})
+
+		It("Should filter and rename the keys of a secret", func() {
+			ctx := context.Background()
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-keys-secret",
+					Namespace: SecretNamespace,
+				},
+				Data: map[string][]byte{
+					"ca.crt":  []byte("ca"),
+					"tls.crt": []byte("certificate"),
+					"tls.key": []byte("private key"),
+				},
+			}
+			Expect(k8sClient.Create(ctx, secret)).Should(Succeed())
+
+			replicatedResource := &utilsv1alpha1.ReplicatedResource{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-replicated-keys-secret",
+					Namespace: ReplicatedResourceNamespace,
+				},
+				Spec: utilsv1alpha1.ReplicatedResourceSpec{
+					Source: utilsv1alpha1.ReplicatedResourceSource{
+						Namespace: SecretNamespace,
+						Name:      secret.Name,
+						Kind:      "Secret",
+					},
+					Transform: utilsv1alpha1.ReplicatedResourceTransform{
+						Keys: &utilsv1alpha1.KeyTransform{
+							Exclude: []string{"*.key"},
+							Rename:  map[string]string{"ca.crt": "ca.pem"},
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, replicatedResource)).Should(Succeed())
+
+			replicatedResourceLookupKey := types.NamespacedName{Name: replicatedResource.Name, Namespace: ReplicatedResourceNamespace}
+			destination := &corev1.Secret{}
+			Eventually(func() map[string][]byte {
+				if err := k8sClient.Get(ctx, replicatedResourceLookupKey, destination); err != nil {
+					return nil
+				}
+				return destination.Data
+			}, timeout, interval).Should(Equal(map[string][]byte{
+				"ca.pem":  []byte("ca"),
+				"tls.crt": []byte("certificate"),
+			}))
+
+			By("By requiring a key that is missing from the source")
+			Eventually(func() error {
+				if err := k8sClient.Get(ctx, replicatedResourceLookupKey, replicatedResource); err != nil {
+					return err
+				}
+				replicatedResource.Spec.Transform.Keys.Include = []string{"tls.crt", "password"}
+				return k8sClient.Update(ctx, replicatedResource)
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func() string {
+				if err := k8sClient.Get(ctx, replicatedResourceLookupKey, replicatedResource); err != nil {
+					return ""
+				}
+				for _, condition := range replicatedResource.Status.Conditions {
+					if condition.Reason == "MissingKey" {
+						return condition.Message
+					}
+				}
+				return ""
+			}, timeout, interval).Should(ContainSubstring(`"password"`))
+			Expect(replicatedResource.Status.Phase).Should(Equal("Failed"))
+		})
+
+		It("Should render templates from the source data", func() {
+			ctx := context.Background()
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-template-secret",
+					Namespace: SecretNamespace,
+				},
+				Data: map[string][]byte{
+					"host":     []byte("db.example.com"),
+					"user":     []byte("app"),
+					"password": []byte("hunter2"),
+				},
+			}
+			Expect(k8sClient.Create(ctx, secret)).Should(Succeed())
+
+			replicatedResource := &utilsv1alpha1.ReplicatedResource{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-replicated-template-secret",
+					Namespace: ReplicatedResourceNamespace,
+				},
+				Spec: utilsv1alpha1.ReplicatedResourceSpec{
+					Source: utilsv1alpha1.ReplicatedResourceSource{
+						Namespace: SecretNamespace,
+						Name:      secret.Name,
+						Kind:      "Secret",
+					},
+					Transform: utilsv1alpha1.ReplicatedResourceTransform{
+						Keys: &utilsv1alpha1.KeyTransform{Include: []string{"password"}},
+						Template: map[string]string{
+							"jdbc.url": "jdbc:postgresql://{{ .Data.host }}/app?user={{ .Data.user }}",
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, replicatedResource)).Should(Succeed())
+
+			replicatedResourceLookupKey := types.NamespacedName{Name: replicatedResource.Name, Namespace: ReplicatedResourceNamespace}
+			destination := &corev1.Secret{}
+			Eventually(func() map[string][]byte {
+				if err := k8sClient.Get(ctx, replicatedResourceLookupKey, destination); err != nil {
+					return nil
+				}
+				return destination.Data
+			}, timeout, interval).Should(Equal(map[string][]byte{
+				"jdbc.url": []byte("jdbc:postgresql://db.example.com/app?user=app"),
+				"password": []byte("hunter2"),
+			}))
+
+			By("By using a key that is missing from the source")
+			Eventually(func() error {
+				if err := k8sClient.Get(ctx, replicatedResourceLookupKey, replicatedResource); err != nil {
+					return err
+				}
+				replicatedResource.Spec.Transform.Template["jdbc.url"] = "jdbc:postgresql://{{ .Data.host }}:{{ .Data.port }}/app"
+				return k8sClient.Update(ctx, replicatedResource)
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func() string {
+				if err := k8sClient.Get(ctx, replicatedResourceLookupKey, replicatedResource); err != nil {
+					return ""
+				}
+				for _, condition := range replicatedResource.Status.Conditions {
+					if condition.Type == utilsv1alpha1.ReplicatedResourceSynced && condition.Reason == "TemplateError" {
+						return condition.Message
+					}
+				}
+				return ""
+			}, timeout, interval).Should(ContainSubstring("template: jdbc.url:1:"))
+		})
+
+		It("Should merge several sources", func() {
+			ctx := context.Background()
+			database := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-merge-database",
+					Namespace: SecretNamespace,
+				},
+				Data: map[string][]byte{"password": []byte("hunter2")},
+			}
+			Expect(k8sClient.Create(ctx, database)).Should(Succeed())
+			ca := &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-merge-ca",
+					Namespace: SecretNamespace,
+				},
+				Data: map[string]string{"ca.crt": "bundle"},
+			}
+			Expect(k8sClient.Create(ctx, ca)).Should(Succeed())
+
+			replicatedResource := &utilsv1alpha1.ReplicatedResource{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-replicated-merge",
+					Namespace: ReplicatedResourceNamespace,
+				},
+				Spec: utilsv1alpha1.ReplicatedResourceSpec{
+					Sources: []utilsv1alpha1.ReplicatedResourceMergeSource{
+						{
+							ReplicatedResourceSource: utilsv1alpha1.ReplicatedResourceSource{
+								Namespace: SecretNamespace,
+								Name:      database.Name,
+								Kind:      "Secret",
+							},
+							KeyPrefix: "db-",
+						},
+						{
+							ReplicatedResourceSource: utilsv1alpha1.ReplicatedResourceSource{
+								Namespace: SecretNamespace,
+								Name:      ca.Name,
+								Kind:      "ConfigMap",
+							},
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, replicatedResource)).Should(Succeed())
+
+			destinationLookupKey := types.NamespacedName{Name: replicatedResource.Name, Namespace: ReplicatedResourceNamespace}
+			destination := &corev1.Secret{}
+			Eventually(func() map[string][]byte {
+				if err := k8sClient.Get(ctx, destinationLookupKey, destination); err != nil {
+					return nil
+				}
+				return destination.Data
+			}, timeout, interval).Should(Equal(map[string][]byte{
+				"db-password": []byte("hunter2"),
+				"ca.crt":      []byte("bundle"),
+			}))
+
+			By("By updating the second source")
+			Eventually(func() error {
+				if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(ca), ca); err != nil {
+					return err
+				}
+				ca.Data["ca.crt"] = "rotated"
+				return k8sClient.Update(ctx, ca)
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func() []byte {
+				if err := k8sClient.Get(ctx, destinationLookupKey, destination); err != nil {
+					return nil
+				}
+				return destination.Data["ca.crt"]
+			}, timeout, interval).Should(Equal([]byte("rotated")))
+		})
+
+		It("Should only replicate what ReplicationPolicies allow", func() {
+			ctx := context.Background()
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-policy-secret",
+					Namespace: SecretNamespace,
+				},
+				Data: map[string][]byte{"test": []byte("this is a test.")},
+			}
+			Expect(k8sClient.Create(ctx, secret)).Should(Succeed())
+
+			policy := &utilsv1alpha1.ReplicationPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-policy"},
+				Spec: utilsv1alpha1.ReplicationPolicySpec{
+					Rules: []utilsv1alpha1.ReplicationPolicyRule{{
+						Source: utilsv1alpha1.ReplicationPolicySource{
+							Names: []string{"test-policy-*"},
+							Kinds: []string{"Secret"},
+						},
+						Destination: utilsv1alpha1.ReplicationPolicyDestination{
+							Namespaces: []string{"payments"},
+						},
+					}},
+				},
+			}
+			Expect(k8sClient.Create(ctx, policy)).Should(Succeed())
+			defer func() {
+				Expect(k8sClient.Delete(ctx, policy)).Should(Succeed())
+			}()
+
+			replicatedResource := &utilsv1alpha1.ReplicatedResource{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-replicated-policy-secret",
+					Namespace: ReplicatedResourceNamespace,
+				},
+				Spec: utilsv1alpha1.ReplicatedResourceSpec{
+					Source: utilsv1alpha1.ReplicatedResourceSource{
+						Namespace: SecretNamespace,
+						Name:      secret.Name,
+						Kind:      "Secret",
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, replicatedResource)).Should(Succeed())
+
+			replicatedResourceLookupKey := client.ObjectKeyFromObject(replicatedResource)
+			Eventually(func() string {
+				if err := k8sClient.Get(ctx, replicatedResourceLookupKey, replicatedResource); err != nil {
+					return ""
+				}
+				for _, condition := range replicatedResource.Status.Conditions {
+					if condition.Type == utilsv1alpha1.ReplicatedResourceAuthorized && condition.Reason == "PolicyDenied" {
+						return condition.Message
+					}
+				}
+				return ""
+			}, timeout, interval).Should(ContainSubstring("ReplicationPolicy test-policy"))
+			Expect(k8sClient.Get(ctx, replicatedResourceLookupKey, &corev1.Secret{})).ShouldNot(Succeed())
+
+			By("By allowing the destination namespace")
+			Eventually(func() error {
+				if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(policy), policy); err != nil {
+					return err
+				}
+				policy.Spec.Rules[0].Destination.Namespaces = append(policy.Spec.Rules[0].Destination.Namespaces, ReplicatedResourceNamespace)
+				return k8sClient.Update(ctx, policy)
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func() error {
+				return k8sClient.Get(ctx, replicatedResourceLookupKey, &corev1.Secret{})
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("Should only replicate to namespaces allowed by the source", func() {
+			ctx := context.Background()
+			namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "test-consent"}}
+			Expect(k8sClient.Create(ctx, namespace)).Should(Succeed())
+
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-consent-secret",
+					Namespace: SecretNamespace,
+				},
+				Data: map[string][]byte{"test": []byte("this is a test.")},
+			}
+			Expect(k8sClient.Create(ctx, secret)).Should(Succeed())
+
+			replicatedResource := &utilsv1alpha1.ReplicatedResource{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-replicated-consent-secret",
+					Namespace: namespace.Name,
+				},
+				Spec: utilsv1alpha1.ReplicatedResourceSpec{
+					Source: utilsv1alpha1.ReplicatedResourceSource{
+						Namespace: SecretNamespace,
+						Name:      secret.Name,
+						Kind:      "Secret",
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, replicatedResource)).Should(Succeed())
+
+			replicatedResourceLookupKey := client.ObjectKeyFromObject(replicatedResource)
+			Eventually(func() string {
+				if err := k8sClient.Get(ctx, replicatedResourceLookupKey, replicatedResource); err != nil {
+					return ""
+				}
+				for _, condition := range replicatedResource.Status.Conditions {
+					if condition.Type == utilsv1alpha1.ReplicatedResourceAuthorized {
+						return condition.Reason
+					}
+				}
+				return ""
+			}, timeout, interval).Should(Equal("Forbidden"))
+			Expect(k8sClient.Get(ctx, replicatedResourceLookupKey, &corev1.Secret{})).ShouldNot(Succeed())
+
+			forbiddenEvents := func(namespace string) func() int {
+				return func() int {
+					events := &corev1.EventList{}
+					if err := k8sClient.List(ctx, events, client.InNamespace(namespace)); err != nil {
+						return 0
+					}
+					count := 0
+					for _, event := range events.Items {
+						if event.Reason == "Forbidden" {
+							count++
+						}
+					}
+					return count
+				}
+			}
+			Eventually(forbiddenEvents(namespace.Name), timeout, interval).ShouldNot(BeZero())
+			Eventually(forbiddenEvents(SecretNamespace), timeout, interval).ShouldNot(BeZero())
+
+			By("By allowing the namespace on the source")
+			Eventually(func() error {
+				if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(secret), secret); err != nil {
+					return err
+				}
+				secret.Annotations = map[string]string{common.AllowedNamespacesAnnotation: namespace.Name}
+				return k8sClient.Update(ctx, secret)
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(func() error {
+				return k8sClient.Get(ctx, replicatedResourceLookupKey, &corev1.Secret{})
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("Should report Ready and the replicated revision once the source exists", func() {
+			ctx := context.Background()
+			replicatedResource := &utilsv1alpha1.ReplicatedResource{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-replicated-conditions-secret",
+					Namespace: ReplicatedResourceNamespace,
+				},
+				Spec: utilsv1alpha1.ReplicatedResourceSpec{
+					Source: utilsv1alpha1.ReplicatedResourceSource{
+						Namespace: SecretNamespace,
+						Name:      "test-conditions-secret",
+						Kind:      "Secret",
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, replicatedResource)).Should(Succeed())
+
+			replicatedResourceLookupKey := client.ObjectKeyFromObject(replicatedResource)
+			condition := func(conditionType string) func() *metav1.Condition {
+				return func() *metav1.Condition {
+					if err := k8sClient.Get(ctx, replicatedResourceLookupKey, replicatedResource); err != nil {
+						return nil
+					}
+					return meta.FindStatusCondition(replicatedResource.Status.Conditions, conditionType)
+				}
+			}
+			Eventually(condition(utilsv1alpha1.ReplicatedResourceSourceFound), timeout, interval).Should(And(
+				Not(BeNil()),
+				HaveField("Status", metav1.ConditionFalse),
+				HaveField("Reason", "SourceNotFound"),
+			))
+			Expect(meta.IsStatusConditionFalse(replicatedResource.Status.Conditions, utilsv1alpha1.ReplicatedResourceReady)).Should(BeTrue())
+
+			By("By creating the source")
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-conditions-secret",
+					Namespace: SecretNamespace,
+				},
+				Data: map[string][]byte{"test": []byte("one")},
+			}
+			Expect(k8sClient.Create(ctx, secret)).Should(Succeed())
+
+			Eventually(condition(utilsv1alpha1.ReplicatedResourceReady), timeout, interval).Should(And(
+				Not(BeNil()),
+				HaveField("Status", metav1.ConditionTrue),
+			))
+			Expect(replicatedResource.Status.ObservedGeneration).Should(Equal(replicatedResource.Generation))
+			for _, conditionType := range []string{utilsv1alpha1.ReplicatedResourceSourceFound, utilsv1alpha1.ReplicatedResourceAuthorized, utilsv1alpha1.ReplicatedResourceSynced} {
+				Expect(meta.IsStatusConditionTrue(replicatedResource.Status.Conditions, conditionType)).Should(BeTrue())
+			}
+			ready := meta.FindStatusCondition(replicatedResource.Status.Conditions, utilsv1alpha1.ReplicatedResourceReady)
+			transitionTime := ready.LastTransitionTime
+
+			destination := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, replicatedResourceLookupKey, destination)).Should(Succeed())
+			Expect(replicatedResource.Status.Sources).Should(ConsistOf(And(
+				HaveField("Kind", "Secret"),
+				HaveField("Name", secret.Name),
+				HaveField("UID", secret.UID),
+				HaveField("ResourceVersion", secret.ResourceVersion),
+			)))
+			Expect(replicatedResource.Status.ContentHash).Should(Equal(destination.Annotations[common.ReplicatedHashAnnotation]))
+			Expect(replicatedResource.Status.Destinations).Should(ConsistOf(And(
+				HaveField("Name", replicatedResource.Name),
+				HaveField("UID", destination.UID),
+				HaveField("State", utilsv1alpha1.DestinationSynced),
+				HaveField("ContentHash", replicatedResource.Status.ContentHash),
+			)))
+			Expect(replicatedResource.Status.LastSyncTime).ShouldNot(BeNil())
+			Expect(replicatedResource.Status.LastSuccessfulSyncTime).ShouldNot(BeNil())
+
+			By("By updating the source")
+			secret.Data = map[string][]byte{"test": []byte("two")}
+			Expect(k8sClient.Update(ctx, secret)).Should(Succeed())
+
+			Eventually(func() []byte {
+				if err := k8sClient.Get(ctx, replicatedResourceLookupKey, destination); err != nil {
+					return nil
+				}
+				return destination.Data["test"]
+			}, timeout, interval).Should(Equal([]byte("two")))
+			Eventually(func() string {
+				if err := k8sClient.Get(ctx, replicatedResourceLookupKey, replicatedResource); err != nil || len(replicatedResource.Status.Sources) == 0 {
+					return ""
+				}
+				return replicatedResource.Status.Sources[0].ResourceVersion
+			}, timeout, interval).Should(Equal(secret.ResourceVersion))
+			Consistently(condition(utilsv1alpha1.ReplicatedResourceReady), time.Second, interval).Should(And(
+				HaveField("Status", metav1.ConditionTrue),
+				HaveField("LastTransitionTime", transitionTime),
+			))
+		})
+
+		It("Should record replication events on the ReplicatedResource and the source", func() {
+			ctx := context.Background()
+			replicatedResource := &utilsv1alpha1.ReplicatedResource{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-replicated-events-secret",
+					Namespace: ReplicatedResourceNamespace,
+				},
+				Spec: utilsv1alpha1.ReplicatedResourceSpec{
+					Source: utilsv1alpha1.ReplicatedResourceSource{
+						Namespace: SecretNamespace,
+						Name:      "test-events-secret",
+						Kind:      "Secret",
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, replicatedResource)).Should(Succeed())
+
+			reasons := func(name string) func() []string {
+				return func() []string {
+					events := &corev1.EventList{}
+					if err := k8sClient.List(ctx, events, client.InNamespace(SecretNamespace)); err != nil {
+						return nil
+					}
+					var reasons []string
+					for _, event := range events.Items {
+						if event.InvolvedObject.Name == name {
+							reasons = append(reasons, event.Reason)
+						}
+					}
+					return reasons
+				}
+			}
+			Eventually(reasons(replicatedResource.Name), timeout, interval).Should(ContainElement("SourceNotFound"))
+
+			By("By creating the source")
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-events-secret",
+					Namespace: SecretNamespace,
+				},
+				Data: map[string][]byte{"test": []byte("one")},
+			}
+			Expect(k8sClient.Create(ctx, secret)).Should(Succeed())
+			Eventually(reasons(replicatedResource.Name), timeout, interval).Should(ContainElement("Replicated"))
+			Eventually(reasons(secret.Name), timeout, interval).Should(ContainElement("Replicated"))
+
+			By("By updating the source")
+			secret.Data = map[string][]byte{"test": []byte("two")}
+			Expect(k8sClient.Update(ctx, secret)).Should(Succeed())
+			Eventually(reasons(replicatedResource.Name), timeout, interval).Should(ContainElement("SourceChanged"))
+		})
+
+		It("Should record replication metrics", func() {
+			ctx := context.Background()
+			replicated := testutil.ToFloat64(replicationsTotal.WithLabelValues("Secret", "success"))
+
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-metrics-secret",
+					Namespace: SecretNamespace,
+				},
+				Data: map[string][]byte{"test": []byte("one")},
+			}
+			Expect(k8sClient.Create(ctx, secret)).Should(Succeed())
+			replicatedResource := &utilsv1alpha1.ReplicatedResource{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-replicated-metrics-secret",
+					Namespace: ReplicatedResourceNamespace,
+				},
+				Spec: utilsv1alpha1.ReplicatedResourceSpec{
+					Source: utilsv1alpha1.ReplicatedResourceSource{
+						Namespace: SecretNamespace,
+						Name:      secret.Name,
+						Kind:      "Secret",
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, replicatedResource)).Should(Succeed())
+
+			Eventually(func() float64 {
+				return testutil.ToFloat64(managedDestinations.WithLabelValues("ReplicatedResource", replicatedResource.Namespace, replicatedResource.Name))
+			}, timeout, interval).Should(Equal(1.0))
+			Expect(testutil.ToFloat64(replicationsTotal.WithLabelValues("Secret", "success"))).Should(BeNumerically(">", replicated))
+			Expect(testutil.ToFloat64(lastSuccessfulSync.WithLabelValues("ReplicatedResource", replicatedResource.Namespace, replicatedResource.Name))).ShouldNot(BeZero())
+
+			By("By resyncing the ReplicatedResource while nothing changed")
+			lastSync := func() float64 {
+				return testutil.ToFloat64(lastSuccessfulSync.WithLabelValues("ReplicatedResource", replicatedResource.Namespace, replicatedResource.Name))
+			}
+			synced := lastSync()
+			var result ctrl.Result
+			Eventually(func() error {
+				var err error
+				result, err = replicatedResourceReconciler.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKeyFromObject(replicatedResource)})
+				return err
+			}, timeout, interval).Should(Succeed())
+			Expect(result.RequeueAfter).Should(Equal(defaultResyncInterval))
+			Expect(lastSync()).Should(BeNumerically(">", synced))
+
+			By("By deleting the ReplicatedResource")
+			Expect(k8sClient.Delete(ctx, replicatedResource)).Should(Succeed())
+			Eventually(func() []string {
+				families, err := ctrlmetrics.Registry.Gather()
+				if err != nil {
+					return nil
+				}
+				var names []string
+				for _, family := range families {
+					if family.GetName() != "resource_replication_destinations" {
+						continue
+					}
+					for _, metric := range family.GetMetric() {
+						for _, label := range metric.GetLabel() {
+							if label.GetName() == "name" {
+								names = append(names, label.GetValue())
+							}
+						}
+					}
+				}
+				return names
+			}, timeout, interval).ShouldNot(ContainElement(replicatedResource.Name))
+		})
+
+		It("Should delete or orphan the destination when the ReplicatedResource is deleted", func() {
+			ctx := context.Background()
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-deletion-secret",
+					Namespace: SecretNamespace,
+				},
+				Data: map[string][]byte{"test": []byte("one")},
+			}
+			Expect(k8sClient.Create(ctx, secret)).Should(Succeed())
+
+			for _, policy := range []utilsv1alpha1.DeletionPolicy{utilsv1alpha1.DeletionDelete, utilsv1alpha1.DeletionOrphan} {
+				By("By replicating with deletion policy " + string(policy))
+				replicatedResource := &utilsv1alpha1.ReplicatedResource{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "test-replicated-deletion-" + strings.ToLower(string(policy)),
+						Namespace: ReplicatedResourceNamespace,
+					},
+					Spec: utilsv1alpha1.ReplicatedResourceSpec{
+						Source: utilsv1alpha1.ReplicatedResourceSource{
+							Namespace: SecretNamespace,
+							Name:      secret.Name,
+							Kind:      "Secret",
+						},
+						DeletionPolicy: policy,
+					},
+				}
+				Expect(k8sClient.Create(ctx, replicatedResource)).Should(Succeed())
+
+				replicatedResourceLookupKey := client.ObjectKeyFromObject(replicatedResource)
+				destination := &corev1.Secret{}
+				Eventually(func() error {
+					return k8sClient.Get(ctx, replicatedResourceLookupKey, destination)
+				}, timeout, interval).Should(Succeed())
+				Eventually(func() []string {
+					if err := k8sClient.Get(ctx, replicatedResourceLookupKey, replicatedResource); err != nil {
+						return nil
+					}
+					return replicatedResource.Finalizers
+				}, timeout, interval).Should(ContainElement("replicated-resource.simopolis.xyz/cleanup"))
+
+				Expect(k8sClient.Delete(ctx, replicatedResource)).Should(Succeed())
+				Eventually(func() bool {
+					return errors.IsNotFound(k8sClient.Get(ctx, replicatedResourceLookupKey, replicatedResource))
+				}, timeout, interval).Should(BeTrue())
+
+				err := k8sClient.Get(ctx, replicatedResourceLookupKey, destination)
+				if policy == utilsv1alpha1.DeletionDelete {
+					Expect(errors.IsNotFound(err)).Should(BeTrue())
+					continue
+				}
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(destination.OwnerReferences).Should(BeEmpty())
+				Expect(destination.Annotations).ShouldNot(HaveKey(common.ReplicatedHashAnnotation))
+				Expect(destination.Data["test"]).Should(Equal([]byte("one")))
+			}
+		})
+
+		It("Should retain or delete the destination when the source is deleted", func() {
+			ctx := context.Background()
+			for _, policy := range []utilsv1alpha1.SourceDeletionPolicy{utilsv1alpha1.SourceDeletedRetain, utilsv1alpha1.SourceDeletedDelete, utilsv1alpha1.SourceDeletedRetainFor} {
+				By("By replicating with source deletion policy " + string(policy))
+				secret := &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "test-source-deletion-" + strings.ToLower(string(policy)),
+						Namespace: SecretNamespace,
+					},
+					Data: map[string][]byte{"test": []byte("one")},
+				}
+				Expect(k8sClient.Create(ctx, secret)).Should(Succeed())
+				replicatedResource := &utilsv1alpha1.ReplicatedResource{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      "test-replicated-source-deletion-" + strings.ToLower(string(policy)),
+						Namespace: ReplicatedResourceNamespace,
+					},
+					Spec: utilsv1alpha1.ReplicatedResourceSpec{
+						Source: utilsv1alpha1.ReplicatedResourceSource{
+							Namespace: SecretNamespace,
+							Name:      secret.Name,
+							Kind:      "Secret",
+						},
+						OnSourceDeleted:          policy,
+						SourceDeletedGracePeriod: &metav1.Duration{Duration: 2 * time.Second},
+					},
+				}
+				Expect(k8sClient.Create(ctx, replicatedResource)).Should(Succeed())
+
+				replicatedResourceLookupKey := client.ObjectKeyFromObject(replicatedResource)
+				destination := &corev1.Secret{}
+				Eventually(func() error {
+					return k8sClient.Get(ctx, replicatedResourceLookupKey, destination)
+				}, timeout, interval).Should(Succeed())
+
+				Expect(k8sClient.Delete(ctx, secret)).Should(Succeed())
+				Eventually(func() *metav1.Time {
+					if err := k8sClient.Get(ctx, replicatedResourceLookupKey, replicatedResource); err != nil {
+						return nil
+					}
+					return replicatedResource.Status.SourceNotFoundSince
+				}, timeout, interval).ShouldNot(BeNil())
+				Expect(meta.IsStatusConditionFalse(replicatedResource.Status.Conditions, utilsv1alpha1.ReplicatedResourceSourceFound)).Should(BeTrue())
+
+				destinationDeleted := func() bool {
+					return errors.IsNotFound(k8sClient.Get(ctx, replicatedResourceLookupKey, destination))
+				}
+				switch policy {
+				case utilsv1alpha1.SourceDeletedRetain:
+					Consistently(destinationDeleted, 3*time.Second, interval).Should(BeFalse())
+					Expect(destination.Data["test"]).Should(Equal([]byte("one")))
+				case utilsv1alpha1.SourceDeletedDelete:
+					Eventually(destinationDeleted, timeout, interval).Should(BeTrue())
+				case utilsv1alpha1.SourceDeletedRetainFor:
+					Expect(destinationDeleted()).Should(BeFalse())
+					Eventually(destinationDeleted, timeout, interval).Should(BeTrue())
+				}
+			}
+		})
+
+		It("Should only adopt an existing destination when the conflict policy allows it", func() {
+			ctx := context.Background()
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-conflict-secret",
+					Namespace: SecretNamespace,
+				},
+				Data: map[string][]byte{"test": []byte("replicated")},
+			}
+			Expect(k8sClient.Create(ctx, secret)).Should(Succeed())
+			existing := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-replicated-conflict-secret",
+					Namespace: ReplicatedResourceNamespace,
+				},
+				Data: map[string][]byte{"test": []byte("by hand")},
+			}
+			Expect(k8sClient.Create(ctx, existing)).Should(Succeed())
+
+			replicatedResource := &utilsv1alpha1.ReplicatedResource{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      existing.Name,
+					Namespace: ReplicatedResourceNamespace,
+				},
+				Spec: utilsv1alpha1.ReplicatedResourceSpec{
+					Source: utilsv1alpha1.ReplicatedResourceSource{
+						Namespace: SecretNamespace,
+						Name:      secret.Name,
+						Kind:      "Secret",
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, replicatedResource)).Should(Succeed())
+
+			replicatedResourceLookupKey := client.ObjectKeyFromObject(replicatedResource)
+			conflict := func() *metav1.Condition {
+				if err := k8sClient.Get(ctx, replicatedResourceLookupKey, replicatedResource); err != nil {
+					return nil
+				}
+				return meta.FindStatusCondition(replicatedResource.Status.Conditions, utilsv1alpha1.ReplicatedResourceDestinationConflict)
+			}
+			Eventually(conflict, timeout, interval).Should(And(
+				Not(BeNil()),
+				HaveField("Status", metav1.ConditionTrue),
+			))
+			Expect(meta.FindStatusCondition(replicatedResource.Status.Conditions, utilsv1alpha1.ReplicatedResourceReady)).Should(HaveField("Reason", "DestinationConflict"))
+			destination := &corev1.Secret{}
+			Expect(k8sClient.Get(ctx, replicatedResourceLookupKey, destination)).Should(Succeed())
+			Expect(destination.Data["test"]).Should(Equal([]byte("by hand")))
+			Expect(destination.OwnerReferences).Should(BeEmpty())
+
+			By("By allowing the destination to be adopted")
+			Eventually(func() error {
+				if err := k8sClient.Get(ctx, replicatedResourceLookupKey, replicatedResource); err != nil {
+					return err
+				}
+				replicatedResource.Spec.ConflictPolicy = utilsv1alpha1.ConflictAdopt
+				return k8sClient.Update(ctx, replicatedResource)
+			}, timeout, interval).Should(Succeed())
+
+			Eventually(conflict, timeout, interval).Should(HaveField("Status", metav1.ConditionFalse))
+			Expect(k8sClient.Get(ctx, replicatedResourceLookupKey, destination)).Should(Succeed())
+			Expect(destination.Data["test"]).Should(Equal([]byte("replicated")))
+			Expect(destination.Annotations).Should(HaveKey(common.AdoptedAnnotation))
+			Expect(metav1.IsControlledBy(destination, replicatedResource)).Should(BeTrue())
+		})
+
+		It("Should replicate to remote clusters", func() {
+			ctx := context.Background()
+			kubeconfig := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-remote-kubeconfig",
+					Namespace: SecretNamespace,
+				},
+				Data: map[string][]byte{"kubeconfig": remoteKubeconfig},
+			}
+			Expect(k8sClient.Create(ctx, kubeconfig)).Should(Succeed())
+			remoteCluster := &utilsv1alpha1.RemoteCluster{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:   "test-remote",
+					Labels: map[string]string{"region": "test"},
+				},
+				Spec: utilsv1alpha1.RemoteClusterSpec{
+					KubeconfigSecretRef: utilsv1alpha1.KubeconfigSecretReference{
+						Namespace: kubeconfig.Namespace,
+						Name:      kubeconfig.Name,
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, remoteCluster)).Should(Succeed())
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-remote-secret",
+					Namespace: SecretNamespace,
+				},
+				Data: map[string][]byte{"test": []byte("one")},
+			}
+			Expect(k8sClient.Create(ctx, secret)).Should(Succeed())
+
+			replicatedResource := &utilsv1alpha1.ReplicatedResource{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-replicated-remote-secret",
+					Namespace: ReplicatedResourceNamespace,
+				},
+				Spec: utilsv1alpha1.ReplicatedResourceSpec{
+					Source: utilsv1alpha1.ReplicatedResourceSource{
+						Namespace: SecretNamespace,
+						Name:      secret.Name,
+						Kind:      "Secret",
+					},
+					Destination: utilsv1alpha1.ReplicatedResourceDestination{
+						Clusters: &utilsv1alpha1.ClusterSelector{
+							Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"region": "test"}},
+						},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, replicatedResource)).Should(Succeed())
+
+			replicatedResourceLookupKey := client.ObjectKeyFromObject(replicatedResource)
+			remoteData := func() []byte {
+				destination := &corev1.Secret{}
+				if err := remoteClient.Get(ctx, replicatedResourceLookupKey, destination); err != nil {
+					return nil
+				}
+				return destination.Data["test"]
+			}
+			Consistently(remoteData, 2*time.Second, interval).Should(BeNil())
+
+			By("By allowing the namespace of the ReplicatedResource")
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(remoteCluster), remoteCluster)).Should(Succeed())
+			remoteCluster.Spec.AllowedNamespaces = &utilsv1alpha1.RemoteClusterAllowedNamespaces{
+				Namespaces: []string{ReplicatedResourceNamespace},
+			}
+			Expect(k8sClient.Update(ctx, remoteCluster)).Should(Succeed())
+			Eventually(remoteData, timeout, interval).Should(Equal([]byte("one")))
+			destination := &corev1.Secret{}
+			Expect(remoteClient.Get(ctx, replicatedResourceLookupKey, destination)).Should(Succeed())
+			Expect(destination.OwnerReferences).Should(BeEmpty())
+			Expect(destination.Annotations).Should(HaveKeyWithValue(common.RemoteOwnerAnnotation, "ReplicatedResource/default/test-replicated-remote-secret"))
+			Expect(k8sClient.Get(ctx, replicatedResourceLookupKey, &corev1.Secret{})).Should(Succeed())
+
+			Eventually(func() []utilsv1alpha1.DestinationStatus {
+				if err := k8sClient.Get(ctx, replicatedResourceLookupKey, replicatedResource); err != nil {
+					return nil
+				}
+				return replicatedResource.Status.Destinations
+			}, timeout, interval).Should(ContainElement(And(
+				HaveField("Cluster", "test-remote"),
+				HaveField("State", utilsv1alpha1.DestinationSynced),
+				HaveField("UID", destination.UID),
+			)))
+
+			By("By updating the source")
+			secret.Data["test"] = []byte("two")
+			Expect(k8sClient.Update(ctx, secret)).Should(Succeed())
+			Eventually(remoteData, timeout, interval).Should(Equal([]byte("two")))
+
+			By("By deleting the ReplicatedResource")
+			Expect(k8sClient.Delete(ctx, replicatedResource)).Should(Succeed())
+			Eventually(func() bool {
+				return errors.IsNotFound(remoteClient.Get(ctx, replicatedResourceLookupKey, destination))
+			}, timeout, interval).Should(BeTrue())
+		})
+
+		It("Should pull a source from a remote cluster", func() {
+			ctx := context.Background()
+			kubeconfig := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-source-kubeconfig",
+					Namespace: ReplicatedResourceNamespace,
+				},
+				Data: map[string][]byte{"config": remoteKubeconfig},
+			}
+			Expect(k8sClient.Create(ctx, kubeconfig)).Should(Succeed())
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-pulled-secret",
+					Namespace: SecretNamespace,
+				},
+				Data: map[string][]byte{"test": []byte("one")},
+			}
+			Expect(remoteClient.Create(ctx, secret)).Should(Succeed())
+
+			replicatedResource := &utilsv1alpha1.ReplicatedResource{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-replicated-pulled-secret",
+					Namespace: ReplicatedResourceNamespace,
+				},
+				Spec: utilsv1alpha1.ReplicatedResourceSpec{
+					Source: utilsv1alpha1.ReplicatedResourceSource{
+						Namespace:  SecretNamespace,
+						Name:       secret.Name,
+						Kind:       "Secret",
+						ClusterRef: &utilsv1alpha1.SourceClusterReference{Name: kubeconfig.Name, Key: "config"},
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, replicatedResource)).Should(Succeed())
+
+			replicatedResourceLookupKey := client.ObjectKeyFromObject(replicatedResource)
+			destinationData := func() []byte {
+				destination := &corev1.Secret{}
+				if err := k8sClient.Get(ctx, replicatedResourceLookupKey, destination); err != nil {
+					return nil
+				}
+				return destination.Data["test"]
+			}
+			Eventually(destinationData, timeout, interval).Should(Equal([]byte("one")))
+			Expect(errors.IsNotFound(k8sClient.Get(ctx, client.ObjectKeyFromObject(secret), &corev1.Secret{}))).Should(BeTrue())
+
+			By("By updating the remote source")
+			secret.Data["test"] = []byte("two")
+			Expect(remoteClient.Update(ctx, secret)).Should(Succeed())
+			Eventually(destinationData, timeout, interval).Should(Equal([]byte("two")))
+
+			By("By deleting the remote source")
+			Expect(remoteClient.Delete(ctx, secret)).Should(Succeed())
+			Eventually(func() bool {
+				if err := k8sClient.Get(ctx, replicatedResourceLookupKey, replicatedResource); err != nil {
+					return false
+				}
+				return meta.IsStatusConditionFalse(replicatedResource.Status.Conditions, utilsv1alpha1.ReplicatedResourceSourceFound)
+			}, timeout, interval).Should(BeTrue())
+		})
+
+		It("Should restart the workloads consuming the destination when its content changes", func() {
+			ctx := context.Background()
+			secret := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-rollout-source",
+					Namespace: SecretNamespace,
+				},
+				Data: map[string][]byte{"test": []byte("one")},
+			}
+			Expect(k8sClient.Create(ctx, secret)).Should(Succeed())
+
+			By("By creating workloads referencing or selected by the ReplicatedResource")
+			template := func(container corev1.Container) corev1.PodTemplateSpec {
+				return corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{"app": "test-rollout"}},
+					Spec:       corev1.PodSpec{Containers: []corev1.Container{container}},
+				}
+			}
+			selector := &metav1.LabelSelector{MatchLabels: map[string]string{"app": "test-rollout"}}
+			referencing := &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-rollout-referencing", Namespace: ReplicatedResourceNamespace},
+				Spec: appsv1.DeploymentSpec{
+					Selector: selector,
+					Template: template(corev1.Container{
+						Name:  "test",
+						Image: "test",
+						EnvFrom: []corev1.EnvFromSource{{
+							SecretRef: &corev1.SecretEnvSource{LocalObjectReference: corev1.LocalObjectReference{Name: "test-rollout-destination"}},
+						}},
+					}),
+				},
+			}
+			labelled := &appsv1.DaemonSet{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-rollout-labelled",
+					Namespace: ReplicatedResourceNamespace,
+					Labels:    map[string]string{"rollout": "test"},
+				},
+				Spec: appsv1.DaemonSetSpec{
+					Selector: selector,
+					Template: template(corev1.Container{Name: "test", Image: "test"}),
+				},
+			}
+			unrelated := &appsv1.Deployment{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-rollout-unrelated", Namespace: ReplicatedResourceNamespace},
+				Spec: appsv1.DeploymentSpec{
+					Selector: selector,
+					Template: template(corev1.Container{Name: "test", Image: "test"}),
+				},
+			}
+			Expect(k8sClient.Create(ctx, referencing)).Should(Succeed())
+			Expect(k8sClient.Create(ctx, labelled)).Should(Succeed())
+			Expect(k8sClient.Create(ctx, unrelated)).Should(Succeed())
+
+			replicatedResource := &utilsv1alpha1.ReplicatedResource{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "test-rollout-destination",
+					Namespace: ReplicatedResourceNamespace,
+				},
+				Spec: utilsv1alpha1.ReplicatedResourceSpec{
+					Source: utilsv1alpha1.ReplicatedResourceSource{
+						Namespace: SecretNamespace,
+						Name:      secret.Name,
+						Kind:      "Secret",
+					},
+					Rollout: &utilsv1alpha1.ReplicatedResourceRollout{
+						Selector:   &metav1.LabelSelector{MatchLabels: map[string]string{"rollout": "test"}},
+						AutoDetect: true,
+					},
+				},
+			}
+			Expect(k8sClient.Create(ctx, replicatedResource)).Should(Succeed())
+
+			replicatedResourceLookupKey := client.ObjectKeyFromObject(replicatedResource)
+			rolloutHash := func() string {
+				if err := k8sClient.Get(ctx, replicatedResourceLookupKey, replicatedResource); err != nil || replicatedResource.Status.Rollout == nil {
+					return ""
+				}
+				return replicatedResource.Status.Rollout.ContentHash
+			}
+			Eventually(rolloutHash, timeout, interval).ShouldNot(BeEmpty())
+			initial := rolloutHash()
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(referencing), referencing)).Should(Succeed())
+			Expect(referencing.Spec.Template.Annotations).ShouldNot(HaveKey(common.ChecksumAnnotation))
+
+			By("By updating the source")
+			secret.Data["test"] = []byte("two")
+			Expect(k8sClient.Update(ctx, secret)).Should(Succeed())
+			Eventually(rolloutHash, timeout, interval).ShouldNot(Equal(initial))
+			hash := rolloutHash()
+
+			checksum := func(obj client.Object) func() string {
+				return func() string {
+					if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+						return ""
+					}
+					return podTemplate(obj).Annotations[common.ChecksumAnnotation]
+				}
+			}
+			Eventually(checksum(referencing), timeout, interval).Should(Equal(hash))
+			Eventually(checksum(labelled), timeout, interval).Should(Equal(hash))
+			Eventually(func() []string {
+				if err := k8sClient.Get(ctx, replicatedResourceLookupKey, replicatedResource); err != nil || replicatedResource.Status.Rollout == nil {
+					return []string{"unknown"}
+				}
+				return replicatedResource.Status.Rollout.Pending
+			}, timeout, interval).Should(BeEmpty())
+			Expect(replicatedResource.Status.Rollout.LastRestartTime).ShouldNot(BeNil())
+			Expect(checksum(unrelated)()).Should(BeEmpty())
+		})
 	})
 })
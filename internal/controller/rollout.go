@@ -0,0 +1,229 @@
+/*
+Copyright 2021-2022 Russell Sim <russell.sim@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	utilsv1alpha1 "github.com/russell/resource-replication-operator/api/v1alpha1"
+	"github.com/russell/resource-replication-operator/replicator/common"
+)
+
+// workloadLists returns an empty list of every kind of workload restarted
+// by spec.rollout.
+func workloadLists() map[string]client.ObjectList {
+	return map[string]client.ObjectList{
+		"DaemonSet":   &appsv1.DaemonSetList{},
+		"Deployment":  &appsv1.DeploymentList{},
+		"StatefulSet": &appsv1.StatefulSetList{},
+	}
+}
+
+// newWorkload returns an empty workload of kind, or nil if kind isn't
+// restarted by spec.rollout.
+func newWorkload(kind string) client.Object {
+	switch kind {
+	case "DaemonSet":
+		return &appsv1.DaemonSet{}
+	case "Deployment":
+		return &appsv1.Deployment{}
+	case "StatefulSet":
+		return &appsv1.StatefulSet{}
+	}
+	return nil
+}
+
+// podTemplate returns the pod template of a workload.
+func podTemplate(obj client.Object) *corev1.PodTemplateSpec {
+	switch workload := obj.(type) {
+	case *appsv1.DaemonSet:
+		return &workload.Spec.Template
+	case *appsv1.Deployment:
+		return &workload.Spec.Template
+	case *appsv1.StatefulSet:
+		return &workload.Spec.Template
+	}
+	return nil
+}
+
+// listWorkloads returns the workloads of namespace by Kind/name.
+func listWorkloads(ctx context.Context, c client.Client, namespace string) (map[string]client.Object, error) {
+	workloads := make(map[string]client.Object)
+	for kind, list := range workloadLists() {
+		if err := c.List(ctx, list, client.InNamespace(namespace)); err != nil {
+			return nil, err
+		}
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			return nil, err
+		}
+		for _, item := range items {
+			if obj, ok := item.(client.Object); ok {
+				workloads[fmt.Sprintf("%s/%s", kind, obj.GetName())] = obj
+			}
+		}
+	}
+	return workloads, nil
+}
+
+// selectWorkloads returns the sorted Kind/name of the workloads of
+// namespace selected by rollout, kind and name being those of the
+// destination.
+func selectWorkloads(ctx context.Context, c client.Client, rollout *utilsv1alpha1.ReplicatedResourceRollout, namespace, kind, name string) ([]string, error) {
+	selector := labels.Nothing()
+	if rollout.Selector != nil {
+		s, err := v1.LabelSelectorAsSelector(rollout.Selector)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid rollout selector: %w", err)
+		}
+		selector = s
+	}
+
+	workloads, err := listWorkloads(ctx, c, namespace)
+	if err != nil {
+		return nil, err
+	}
+	var selected []string
+	for key, obj := range workloads {
+		if selector.Matches(labels.Set(obj.GetLabels())) || (rollout.AutoDetect && references(podTemplate(obj), kind, name)) {
+			selected = append(selected, key)
+		}
+	}
+	slices.Sort(selected)
+	return selected, nil
+}
+
+// references reports whether template uses the Secret or ConfigMap, as
+// given by kind, named name in a volume, env or envFrom.
+func references(template *corev1.PodTemplateSpec, kind, name string) bool {
+	for _, volume := range template.Spec.Volumes {
+		switch {
+		case kind == "Secret" && volume.Secret != nil && volume.Secret.SecretName == name:
+			return true
+		case kind == "ConfigMap" && volume.ConfigMap != nil && volume.ConfigMap.Name == name:
+			return true
+		case volume.Projected != nil:
+			for _, source := range volume.Projected.Sources {
+				if kind == "Secret" && source.Secret != nil && source.Secret.Name == name {
+					return true
+				}
+				if kind == "ConfigMap" && source.ConfigMap != nil && source.ConfigMap.Name == name {
+					return true
+				}
+			}
+		}
+	}
+
+	for _, container := range append(slices.Clone(template.Spec.InitContainers), template.Spec.Containers...) {
+		for _, env := range container.Env {
+			if env.ValueFrom == nil {
+				continue
+			}
+			if kind == "Secret" && env.ValueFrom.SecretKeyRef != nil && env.ValueFrom.SecretKeyRef.Name == name {
+				return true
+			}
+			if kind == "ConfigMap" && env.ValueFrom.ConfigMapKeyRef != nil && env.ValueFrom.ConfigMapKeyRef.Name == name {
+				return true
+			}
+		}
+		for _, envFrom := range container.EnvFrom {
+			if kind == "Secret" && envFrom.SecretRef != nil && envFrom.SecretRef.Name == name {
+				return true
+			}
+			if kind == "ConfigMap" && envFrom.ConfigMapRef != nil && envFrom.ConfigMapRef.Name == name {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// rollout restarts the workloads selected by rr.Spec.Rollout once the
+// content hash of the destination, of kind kind, differs from the one they
+// were restarted for. The workloads are restarted one at a time as
+// r.rolloutLimiter allows, it returns when to continue the rollout if
+// workloads are still pending. The content the workloads started with is
+// recorded without restarting them.
+func (r *ReplicatedResourceReconciler) rollout(ctx context.Context, log logr.Logger, rr *utilsv1alpha1.ReplicatedResource, kind, hash string, now v1.Time) (time.Duration, error) {
+	status := rr.Status.Rollout
+	if status == nil || status.ContentHash == "" {
+		rr.Status.Rollout = &utilsv1alpha1.RolloutStatus{ContentHash: hash}
+		return 0, nil
+	}
+	if status.ContentHash != hash {
+		selected, err := selectWorkloads(ctx, r.Client, rr.Spec.Rollout, rr.Namespace, kind, rr.DestinationName())
+		if err != nil {
+			return 0, err
+		}
+		status.ContentHash = hash
+		status.Pending = selected
+	}
+
+	for len(status.Pending) > 0 {
+		key := status.Pending[0]
+		workloadKind, name, _ := strings.Cut(key, "/")
+		obj := newWorkload(workloadKind)
+		if obj == nil {
+			status.Pending = status.Pending[1:]
+			continue
+		}
+		if err := r.Get(ctx, types.NamespacedName{Namespace: rr.Namespace, Name: name}, obj); err != nil {
+			if kerrors.IsNotFound(err) {
+				status.Pending = status.Pending[1:]
+				continue
+			}
+			return 0, err
+		}
+		template := podTemplate(obj)
+		if template.Annotations[common.ChecksumAnnotation] == hash {
+			status.Pending = status.Pending[1:]
+			continue
+		}
+		if !r.rolloutLimiter.TryAccept() {
+			return r.RolloutInterval, nil
+		}
+
+		patch := client.MergeFrom(obj.DeepCopyObject().(client.Object))
+		if template.Annotations == nil {
+			template.Annotations = make(map[string]string)
+		}
+		template.Annotations[common.ChecksumAnnotation] = hash
+		if err := r.Patch(ctx, obj, patch); err != nil {
+			return 0, err
+		}
+		log.Info(fmt.Sprintf("Restarted %s", key))
+		r.Recorder.Eventf(rr, corev1.EventTypeNormal, eventWorkloadRestarted, "Restarted %s as %s changed", key, rr.DestinationName())
+		status.Pending = status.Pending[1:]
+		status.LastRestartTime = &now
+	}
+	status.Pending = nil
+	return 0, nil
+}
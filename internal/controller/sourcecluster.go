@@ -0,0 +1,110 @@
+/*
+Copyright 2021-2022 Russell Sim <russell.sim@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	utilsv1alpha1 "github.com/russell/resource-replication-operator/api/v1alpha1"
+	"github.com/russell/resource-replication-operator/internal/remotecluster"
+	"github.com/russell/resource-replication-operator/replicator"
+)
+
+const (
+	// reasonSourceClusterUnavailable is the reason of the errors of
+	// reading a source from a cluster that can't be reached.
+	reasonSourceClusterUnavailable = "SourceClusterUnavailable"
+	// reasonSourceClusterForbidden is the reason of the errors of reading
+	// a source that the credentials of its kubeconfig don't allow.
+	reasonSourceClusterForbidden = "SourceClusterForbidden"
+)
+
+// remoteSource returns the remote source read by rr for source, ok is false
+// if source is in the local cluster.
+func remoteSource(rr *utilsv1alpha1.ReplicatedResource, source utilsv1alpha1.ReplicatedResourceSource) (remote remotecluster.Source, ok bool) {
+	if source.ClusterRef == nil {
+		return remote, false
+	}
+	return remotecluster.Source{
+		Kubeconfig: types.NamespacedName{Namespace: rr.Namespace, Name: source.ClusterRef.Name},
+		Key:        source.ClusterRef.Key,
+		GVK:        source.GroupVersionKind(),
+		Object:     types.NamespacedName{Namespace: source.Namespace, Name: source.Name},
+	}, true
+}
+
+// remoteSources returns the sources of rr that are read from other
+// clusters.
+func remoteSources(rr *utilsv1alpha1.ReplicatedResource) []remotecluster.Source {
+	var sources []remotecluster.Source
+	for _, source := range rr.Spec.AllSources() {
+		if remote, ok := remoteSource(rr, source); ok {
+			sources = append(sources, remote)
+		}
+	}
+	return sources
+}
+
+// sourceClient returns the client that reads source, which is the
+// reconciler's client unless the source is in another cluster.
+func (r *ReplicatedResourceReconciler) sourceClient(ctx context.Context, rr *utilsv1alpha1.ReplicatedResource, source utilsv1alpha1.ReplicatedResourceSource, kindReplicator replicator.Replicator) (client.Client, error) {
+	remote, ok := remoteSource(rr, source)
+	if !ok {
+		return r.Client, nil
+	}
+	c, err := r.remoteSources.For(ctx, client.ObjectKeyFromObject(rr), remote, kindReplicator.NewObject())
+	if err != nil {
+		return nil, sourceClusterError(remote, err)
+	}
+	return c, nil
+}
+
+// sourceClusterError reports err, which happened while reading the remote
+// source, as SourceClusterForbidden or SourceClusterUnavailable unless it's
+// a replication error or the source wasn't found.
+func sourceClusterError(remote remotecluster.Source, err error) error {
+	var replicationErr *replicator.Error
+	if kerrors.IsNotFound(err) || errors.As(err, &replicationErr) {
+		return err
+	}
+	reason := reasonSourceClusterUnavailable
+	if kerrors.IsForbidden(err) || kerrors.IsUnauthorized(err) {
+		reason = reasonSourceClusterForbidden
+	}
+	return &replicator.Error{
+		Reason:  reason,
+		Message: fmt.Sprintf("cluster of Secret %s: %s", remote.Kubeconfig, err),
+	}
+}
+
+// findObjectsForRemoteSource returns requests for every ReplicatedResource
+// reading remote, whose object changed.
+func (r *ReplicatedResourceReconciler) findObjectsForRemoteSource(ctx context.Context, remote remotecluster.Source) []reconcile.Request {
+	r.Log.Info(fmt.Sprintf("Remote source %s updated triggering a refresh", remote))
+	var requests []reconcile.Request
+	for _, owner := range r.remoteSources.Owners(remote) {
+		requests = append(requests, reconcile.Request{NamespacedName: owner})
+	}
+	return requests
+}
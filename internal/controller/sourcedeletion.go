@@ -0,0 +1,64 @@
+/*
+Copyright 2021-2022 Russell Sim <russell.sim@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"time"
+
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	utilsv1alpha1 "github.com/russell/resource-replication-operator/api/v1alpha1"
+)
+
+// defaultSourceDeletedGracePeriod is how long the RetainFor policy keeps
+// the destinations when no grace period is set.
+const defaultSourceDeletedGracePeriod = 24 * time.Hour
+
+// trackSourceNotFound returns when the source was first found missing,
+// given since, the previous value, and whether it's missing now.
+func trackSourceNotFound(since *v1.Time, missing bool, now v1.Time) *v1.Time {
+	if !missing {
+		return nil
+	}
+	if since == nil {
+		return &now
+	}
+	return since
+}
+
+// sourceDeletionDue returns whether the destinations of a source that has
+// been missing since since must be deleted according to policy and, if
+// they must be later, how long until then.
+func sourceDeletionDue(policy utilsv1alpha1.SourceDeletionPolicy, gracePeriod *v1.Duration, since *v1.Time, now time.Time) (bool, time.Duration) {
+	if since == nil {
+		return false, 0
+	}
+	switch policy {
+	case utilsv1alpha1.SourceDeletedDelete:
+		return true, 0
+	case utilsv1alpha1.SourceDeletedRetainFor:
+		retainFor := defaultSourceDeletedGracePeriod
+		if gracePeriod != nil {
+			retainFor = gracePeriod.Duration
+		}
+		if remaining := since.Add(retainFor).Sub(now); remaining > 0 {
+			return false, remaining
+		}
+		return true, 0
+	}
+	return false, 0
+}
@@ -0,0 +1,96 @@
+/*
+Copyright 2021-2022 Russell Sim <russell.sim@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controller
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	utilsv1alpha1 "github.com/russell/resource-replication-operator/api/v1alpha1"
+	"github.com/russell/resource-replication-operator/replicator"
+)
+
+// sourceRevisions returns the revisions of the replicated sources, which
+// are in the order of sources.
+func sourceRevisions(sources []utilsv1alpha1.ReplicatedResourceSource, result replicator.Result) []utilsv1alpha1.SourceRevision {
+	revisions := make([]utilsv1alpha1.SourceRevision, 0, len(result.Sources))
+	for i, obj := range result.Sources {
+		if i >= len(sources) {
+			break
+		}
+		apiVersion, kind := sources[i].GroupVersionKind().ToAPIVersionAndKind()
+		revisions = append(revisions, utilsv1alpha1.SourceRevision{
+			APIVersion:      apiVersion,
+			Kind:            kind,
+			Namespace:       obj.GetNamespace(),
+			Name:            obj.GetName(),
+			UID:             obj.GetUID(),
+			ResourceVersion: obj.GetResourceVersion(),
+		})
+	}
+	return revisions
+}
+
+// destinationStatus returns the status of the destination key, in the
+// RemoteCluster named cluster or the local cluster if it's empty, after a
+// replication, err being nil if it succeeded. A failed destination keeps
+// the UID, hash and sync time of its previous status.
+func destinationStatus(cluster string, source utilsv1alpha1.ReplicatedResourceSource, key types.NamespacedName, policy utilsv1alpha1.DriftPolicy, result replicator.Result, err error, now metav1.Time, previous []utilsv1alpha1.DestinationStatus) utilsv1alpha1.DestinationStatus {
+	apiVersion, kind := source.GroupVersionKind().ToAPIVersionAndKind()
+	status := utilsv1alpha1.DestinationStatus{
+		Cluster:    cluster,
+		APIVersion: apiVersion,
+		Kind:       kind,
+		Namespace:  key.Namespace,
+		Name:       key.Name,
+	}
+
+	if err != nil {
+		for _, destination := range previous {
+			if destination.Cluster == cluster && destination.Kind == kind && destination.Namespace == key.Namespace && destination.Name == key.Name {
+				status.UID = destination.UID
+				status.ContentHash = destination.ContentHash
+				status.LastSyncTime = destination.LastSyncTime
+			}
+		}
+		status.State = utilsv1alpha1.DestinationFailed
+		status.Message = err.Error()
+		return status
+	}
+
+	status.State = utilsv1alpha1.DestinationSynced
+	if result.Drifted && policy == utilsv1alpha1.DriftReportOnly {
+		status.State = utilsv1alpha1.DestinationDrifted
+	}
+	if result.Object != nil {
+		status.UID = result.Object.GetUID()
+	}
+	status.ContentHash = result.Hash
+	status.LastSyncTime = &now
+	return status
+}
+
+// describeDestination returns the cluster qualified key of destination.
+func describeDestination(destination utilsv1alpha1.DestinationStatus) string {
+	key := types.NamespacedName{Namespace: destination.Namespace, Name: destination.Name}.String()
+	if destination.Cluster != "" {
+		return fmt.Sprintf("%s/%s", destination.Cluster, key)
+	}
+	return key
+}
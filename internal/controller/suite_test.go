@@ -20,6 +20,7 @@ import (
 	"context"
 	"path/filepath"
 	"testing"
+	"time"
 
 	. "github.com/onsi/ginkgo/v2"
 	. "github.com/onsi/gomega"
@@ -41,9 +42,19 @@ import (
 var cfg *rest.Config
 var k8sClient client.Client
 var testEnv *envtest.Environment
+
+// remoteClient and remoteKubeconfig access a second API server that
+// ReplicatedResources replicate to through a RemoteCluster.
+var remoteClient client.Client
+var remoteEnv *envtest.Environment
+var remoteKubeconfig []byte
 var ctx context.Context
 var cancel context.CancelFunc
 
+// replicatedResourceReconciler is the reconciler registered with the
+// manager, for tests that reconcile directly.
+var replicatedResourceReconciler *ReplicatedResourceReconciler
+
 func TestAPIs(t *testing.T) {
 	RegisterFailHandler(Fail)
 
@@ -76,15 +87,42 @@ var _ = BeforeSuite(func() {
 	Expect(err).NotTo(HaveOccurred())
 	Expect(k8sClient).NotTo(BeNil())
 
+	By("bootstrapping the remote cluster")
+	remoteEnv = &envtest.Environment{}
+	remoteCfg, err := remoteEnv.Start()
+	Expect(err).NotTo(HaveOccurred())
+	remoteClient, err = client.New(remoteCfg, client.Options{Scheme: scheme.Scheme})
+	Expect(err).NotTo(HaveOccurred())
+	remoteUser, err := remoteEnv.AddUser(envtest.User{Name: "replication-operator", Groups: []string{"system:masters"}}, nil)
+	Expect(err).NotTo(HaveOccurred())
+	remoteKubeconfig, err = remoteUser.KubeConfig()
+	Expect(err).NotTo(HaveOccurred())
+
 	k8sManager, err := ctrl.NewManager(cfg, ctrl.Options{
 		Scheme: scheme.Scheme,
 	})
 	Expect(err).ToNot(HaveOccurred())
 
-	err = (&ReplicatedResourceReconciler{
+	replicatedResourceReconciler = &ReplicatedResourceReconciler{
+		Client:          k8sManager.GetClient(),
+		Scheme:          k8sManager.GetScheme(),
+		Log:             ctrl.Log.WithName("controllers").WithName("ReplicatedResource"),
+		RolloutInterval: time.Second,
+	}
+	err = replicatedResourceReconciler.SetupWithManager(k8sManager)
+	Expect(err).ToNot(HaveOccurred())
+
+	err = (&ClusterReplicatedResourceReconciler{
+		Client: k8sManager.GetClient(),
+		Scheme: k8sManager.GetScheme(),
+		Log:    ctrl.Log.WithName("controllers").WithName("ClusterReplicatedResource"),
+	}).SetupWithManager(k8sManager)
+	Expect(err).ToNot(HaveOccurred())
+
+	err = (&AnnotatedSourceReconciler{
 		Client: k8sManager.GetClient(),
 		Scheme: k8sManager.GetScheme(),
-		Log:    ctrl.Log.WithName("controllers").WithName("ReplicatedResource"),
+		Log:    ctrl.Log.WithName("controllers").WithName("AnnotatedSource"),
 	}).SetupWithManager(k8sManager)
 	Expect(err).ToNot(HaveOccurred())
 
@@ -100,4 +138,6 @@ var _ = AfterSuite(func() {
 	By("tearing down the test environment")
 	err := testEnv.Stop()
 	Expect(err).NotTo(HaveOccurred())
+	err = remoteEnv.Stop()
+	Expect(err).NotTo(HaveOccurred())
 })
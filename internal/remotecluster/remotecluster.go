@@ -0,0 +1,120 @@
+/*
+Copyright 2021-2022 Russell Sim <russell.sim@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+// Package remotecluster builds and caches clients for the clusters
+// described by RemoteClusters.
+package remotecluster
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	utilsv1alpha1 "github.com/russell/resource-replication-operator/api/v1alpha1"
+)
+
+// DefaultKubeconfigKey is the key of the kubeconfig in the Secret of a
+// RemoteCluster that doesn't set one.
+const DefaultKubeconfigKey = "kubeconfig"
+
+// requestTimeout bounds the requests to remote clusters so that an
+// unreachable cluster doesn't hold up a reconcile.
+const requestTimeout = 30 * time.Second
+
+// Clients builds a client for each RemoteCluster and caches it until the
+// kubeconfig Secret of the RemoteCluster changes.
+type Clients struct {
+	// Client reads RemoteClusters and their kubeconfig Secrets.
+	Client client.Reader
+	// Scheme is used by the remote clients.
+	Scheme *runtime.Scheme
+
+	mu      sync.Mutex
+	clients map[string]cachedClient
+}
+
+// cachedClient is a client built from the kubeconfig Secret at version,
+// its UID and resource version.
+type cachedClient struct {
+	version string
+	client  client.Client
+}
+
+// Get returns a client for the RemoteCluster named name.
+func (c *Clients) Get(ctx context.Context, name string) (client.Client, error) {
+	cluster := &utilsv1alpha1.RemoteCluster{}
+	if err := c.Client.Get(ctx, types.NamespacedName{Name: name}, cluster); err != nil {
+		return nil, err
+	}
+	return c.For(ctx, cluster)
+}
+
+// For returns a client for cluster.
+func (c *Clients) For(ctx context.Context, cluster *utilsv1alpha1.RemoteCluster) (client.Client, error) {
+	ref := cluster.Spec.KubeconfigSecretRef
+	secret := &corev1.Secret{}
+	if err := c.Client.Get(ctx, types.NamespacedName{Namespace: ref.Namespace, Name: ref.Name}, secret); err != nil {
+		return nil, fmt.Errorf("Could not read the kubeconfig of cluster %s: %v", cluster.Name, err)
+	}
+	version := fmt.Sprintf("%s/%s", secret.UID, secret.ResourceVersion)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if cached, ok := c.clients[cluster.Name]; ok && cached.version == version {
+		return cached.client, nil
+	}
+
+	config, err := restConfig(secret, ref.Key)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid kubeconfig for cluster %s: %v", cluster.Name, err)
+	}
+	remote, err := client.New(config, client.Options{Scheme: c.Scheme})
+	if err != nil {
+		return nil, fmt.Errorf("Could not create a client for cluster %s: %v", cluster.Name, err)
+	}
+
+	if c.clients == nil {
+		c.clients = make(map[string]cachedClient)
+	}
+	c.clients[cluster.Name] = cachedClient{version: version, client: remote}
+	return remote, nil
+}
+
+// restConfig returns the config of the kubeconfig under key in secret, or
+// under DefaultKubeconfigKey if key is empty.
+func restConfig(secret *corev1.Secret, key string) (*rest.Config, error) {
+	if key == "" {
+		key = DefaultKubeconfigKey
+	}
+	kubeconfig, ok := secret.Data[key]
+	if !ok {
+		return nil, fmt.Errorf("Secret %s/%s has no key %s", secret.Namespace, secret.Name, key)
+	}
+	config, err := clientcmd.RESTConfigFromKubeConfig(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	config.Timeout = requestTimeout
+	return config, nil
+}
@@ -0,0 +1,310 @@
+/*
+Copyright 2021-2022 Russell Sim <russell.sim@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package remotecluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	toolscache "k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+)
+
+// Source is an object read from the cluster of a kubeconfig Secret.
+type Source struct {
+	// Kubeconfig is the Secret holding the kubeconfig of the cluster.
+	Kubeconfig types.NamespacedName
+	// Key of the kubeconfig in the Secret, defaults to
+	// DefaultKubeconfigKey.
+	Key    string
+	GVK    schema.GroupVersionKind
+	Object types.NamespacedName
+}
+
+// String describes the source and its cluster.
+func (s Source) String() string {
+	return fmt.Sprintf("%s %s in the cluster of Secret %s", s.GVK.Kind, s.Object, s.Kubeconfig)
+}
+
+// Sources runs an informer for every Source that is read, restricted to
+// the source object, and stops it once no owner reads the source anymore
+// or its kubeconfig Secret changes.
+type Sources struct {
+	// Client reads the kubeconfig Secrets.
+	Client client.Reader
+	// Scheme is used by the remote caches.
+	Scheme *runtime.Scheme
+	// Events receives the sources whose object was created, updated or
+	// deleted, so that their owners can be requeued. It is read by a
+	// single channel watch rather than one watch per informer, which
+	// would outlive the informer when it is restarted.
+	Events chan event.TypedGenericEvent[Source]
+
+	mu      sync.Mutex
+	ctx     context.Context
+	cancel  context.CancelFunc
+	sources map[Source]*watchedSource
+
+	// owners are guarded by their own lock so that the events of a source
+	// aren't held up while the informer of another one syncs.
+	ownersMu sync.Mutex
+	owners   map[types.NamespacedName]map[Source]bool
+}
+
+// watchErrorGrace is how long a failed watch marks a source as unavailable,
+// the informer retries failed watches well within it.
+const watchErrorGrace = time.Minute
+
+// watchedSource is the informer cache of a source, built from the
+// kubeconfig Secret at version, its UID and resource version.
+type watchedSource struct {
+	version string
+	// ready is closed once the informer synced, or failed to with err.
+	ready  chan struct{}
+	err    error
+	client client.Client
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// lastWatchError is the last time the watch of the source failed.
+	lastWatchError atomic.Pointer[time.Time]
+	watchError     atomic.Pointer[error]
+}
+
+// failing returns the last error of the watch if it failed recently, as
+// the cache then serves the last object it read.
+func (w *watchedSource) failing() error {
+	last := w.lastWatchError.Load()
+	if last == nil || time.Since(*last) > watchErrorGrace {
+		return nil
+	}
+	return *w.watchError.Load()
+}
+
+// watchFailed records err unless the watch merely expired or was closed.
+func (w *watchedSource) watchFailed(ctx context.Context, r *toolscache.Reflector, err error) {
+	toolscache.DefaultWatchErrorHandler(ctx, r, err)
+	if errors.Is(err, io.EOF) || kerrors.IsResourceExpired(err) || kerrors.IsGone(err) {
+		return
+	}
+	now := time.Now()
+	w.watchError.Store(&err)
+	w.lastWatchError.Store(&now)
+}
+
+// For returns a client reading source from the cache of its informer,
+// which is started if needed, and records that owner reads source. obj is
+// an empty object of the kind of source. The informer of a new source
+// syncs without holding up the other sources, concurrent callers for the
+// same source wait for it. An error is returned while the watch of the
+// source fails, rather than a client reading its last known state.
+func (s *Sources) For(ctx context.Context, owner types.NamespacedName, source Source, obj client.Object) (client.Client, error) {
+	secret := &corev1.Secret{}
+	if err := s.Client.Get(ctx, source.Kubeconfig, secret); err != nil {
+		return nil, fmt.Errorf("Could not read the kubeconfig Secret %s: %w", source.Kubeconfig, err)
+	}
+	version := fmt.Sprintf("%s/%s", secret.UID, secret.ResourceVersion)
+
+	s.ownersMu.Lock()
+	if s.owners == nil {
+		s.owners = make(map[types.NamespacedName]map[Source]bool)
+	}
+	if s.owners[owner] == nil {
+		s.owners[owner] = make(map[Source]bool)
+	}
+	s.owners[owner][source] = true
+	s.ownersMu.Unlock()
+
+	s.mu.Lock()
+	if s.sources == nil {
+		s.ctx, s.cancel = context.WithCancel(context.Background())
+		s.sources = make(map[Source]*watchedSource)
+	}
+	watched, ok := s.sources[source]
+	if ok && watched.version != version {
+		watched.cancel()
+		delete(s.sources, source)
+		ok = false
+	}
+	if !ok {
+		watched = &watchedSource{version: version, ready: make(chan struct{})}
+		watched.ctx, watched.cancel = context.WithCancel(s.ctx)
+		s.sources[source] = watched
+	}
+	s.mu.Unlock()
+
+	if !ok {
+		watched.client, watched.err = s.start(ctx, watched, source, secret, obj)
+		if watched.err != nil {
+			watched.cancel()
+			s.mu.Lock()
+			if s.sources[source] == watched {
+				delete(s.sources, source)
+			}
+			s.mu.Unlock()
+		}
+		close(watched.ready)
+	}
+
+	select {
+	case <-watched.ready:
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	if watched.err != nil {
+		return nil, watched.err
+	}
+	if err := watched.failing(); err != nil {
+		return nil, fmt.Errorf("Watch of %s is failing: %w", source, err)
+	}
+	return watched.client, nil
+}
+
+// start starts the informer of watched for source and waits for it to
+// sync.
+func (s *Sources) start(ctx context.Context, watched *watchedSource, source Source, secret *corev1.Secret, obj client.Object) (client.Client, error) {
+	config, err := restConfig(secret, source.Key)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid kubeconfig in Secret %s: %w", source.Kubeconfig, err)
+	}
+	sourceCache, err := cache.New(config, cache.Options{
+		Scheme:                   s.Scheme,
+		DefaultNamespaces:        map[string]cache.Config{source.Object.Namespace: {}},
+		DefaultFieldSelector:     fields.OneTermEqualSelector("metadata.name", source.Object.Name),
+		DefaultWatchErrorHandler: watched.watchFailed,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Could not create a cache for %s: %w", source, err)
+	}
+	sourceClient, err := client.New(config, client.Options{
+		Scheme: s.Scheme,
+		Cache:  &client.CacheOptions{Reader: sourceCache, Unstructured: true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("Could not create a client for %s: %w", source, err)
+	}
+
+	go func() {
+		_ = sourceCache.Start(watched.ctx)
+	}()
+	syncCtx, syncCancel := context.WithTimeout(ctx, requestTimeout)
+	defer syncCancel()
+	informer, err := sourceCache.GetInformer(syncCtx, obj)
+	if err != nil {
+		// An informer that can't list never syncs, report why
+		if watchErr := watched.failing(); watchErr != nil {
+			err = watchErr
+		}
+		return nil, fmt.Errorf("Could not watch %s: %w", source, err)
+	}
+	// The handler stops with the cache of the informer
+	if _, err := informer.AddEventHandler(toolscache.ResourceEventHandlerFuncs{
+		AddFunc: func(interface{}) { s.notify(watched.ctx, source) },
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			if oldObj.(client.Object).GetResourceVersion() != newObj.(client.Object).GetResourceVersion() {
+				s.notify(watched.ctx, source)
+			}
+		},
+		DeleteFunc: func(interface{}) { s.notify(watched.ctx, source) },
+	}); err != nil {
+		return nil, fmt.Errorf("Could not watch %s: %w", source, err)
+	}
+	return sourceClient, nil
+}
+
+// notify sends source to Events, unless the informer of source is stopped
+// first.
+func (s *Sources) notify(ctx context.Context, source Source) {
+	if s.Events == nil {
+		return
+	}
+	select {
+	case s.Events <- event.TypedGenericEvent[Source]{Object: source}:
+	case <-ctx.Done():
+	}
+}
+
+// Owners returns the owners that read source.
+func (s *Sources) Owners(source Source) []types.NamespacedName {
+	s.ownersMu.Lock()
+	defer s.ownersMu.Unlock()
+
+	var owners []types.NamespacedName
+	for owner, sources := range s.owners {
+		if sources[source] {
+			owners = append(owners, owner)
+		}
+	}
+	return owners
+}
+
+// Forget records that owner only reads the sources in keep, and stops the
+// informers of the sources that no owner reads anymore.
+func (s *Sources) Forget(owner types.NamespacedName, keep []Source) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.ownersMu.Lock()
+	for source := range s.owners[owner] {
+		if !slices.Contains(keep, source) {
+			delete(s.owners[owner], source)
+		}
+	}
+	if len(s.owners[owner]) == 0 {
+		delete(s.owners, owner)
+	}
+	read := make(map[Source]bool)
+	for _, sources := range s.owners {
+		for source := range sources {
+			read[source] = true
+		}
+	}
+	s.ownersMu.Unlock()
+
+	for source, watched := range s.sources {
+		if !read[source] {
+			watched.cancel()
+			delete(s.sources, source)
+		}
+	}
+}
+
+// Start implements manager.Runnable, it stops every informer when ctx is
+// done.
+func (s *Sources) Start(ctx context.Context) error {
+	<-ctx.Done()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.cancel != nil {
+		s.cancel()
+	}
+	return nil
+}
@@ -0,0 +1,356 @@
+/*
+Copyright 2021-2022 Russell Sim <russell.sim@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	utilsv1alpha1 "github.com/russell/resource-replication-operator/api/v1alpha1"
+	"github.com/russell/resource-replication-operator/internal/accessreview"
+	"github.com/russell/resource-replication-operator/replicator"
+	"github.com/russell/resource-replication-operator/replicator/common"
+)
+
+// log is for logging in this package.
+var replicatedresourcelog = logf.Log.WithName("replicatedresource-resource")
+
+// SetupReplicatedResourceWebhookWithManager registers the webhooks for
+// ReplicatedResource in the manager.
+func SetupReplicatedResourceWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).For(&utilsv1alpha1.ReplicatedResource{}).
+		WithValidator(&ReplicatedResourceCustomValidator{
+			Client:      mgr.GetClient(),
+			RESTMapper:  mgr.GetRESTMapper(),
+			Replicators: replicator.NewRegistry(replicatedresourcelog),
+		}).
+		WithDefaulter(&ReplicatedResourceCustomDefaulter{}).
+		Complete()
+}
+
+// +kubebuilder:webhook:path=/mutate-utils-simopolis-xyz-v1alpha1-replicatedresource,mutating=true,failurePolicy=fail,sideEffects=None,groups=utils.simopolis.xyz,resources=replicatedresources,verbs=create;update,versions=v1alpha1,name=mreplicatedresource-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// ReplicatedResourceCustomDefaulter defaults the namespace of the sources
+// to the namespace of the ReplicatedResource and records the user creating
+// or retargeting it, whose access to the sources is reviewed by
+// ReplicatedResourceCustomValidator.
+type ReplicatedResourceCustomDefaulter struct{}
+
+var _ webhook.CustomDefaulter = &ReplicatedResourceCustomDefaulter{}
+
+// Default implements webhook.CustomDefaulter.
+func (d *ReplicatedResourceCustomDefaulter) Default(ctx context.Context, obj runtime.Object) error {
+	rr, ok := obj.(*utilsv1alpha1.ReplicatedResource)
+	if !ok {
+		return fmt.Errorf("expected a ReplicatedResource object but got %T", obj)
+	}
+	replicatedresourcelog.Info("Defaulting for ReplicatedResource", "name", rr.GetName())
+
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	namespace := rr.Namespace
+	if namespace == "" {
+		namespace = req.Namespace
+	}
+	if rr.Spec.Source.Namespace == "" && len(rr.Spec.Sources) == 0 {
+		rr.Spec.Source.Namespace = namespace
+	}
+	for i := range rr.Spec.Sources {
+		if rr.Spec.Sources[i].Namespace == "" {
+			rr.Spec.Sources[i].Namespace = namespace
+		}
+	}
+
+	old, err := oldReplicatedResource(req)
+	if err != nil {
+		return err
+	}
+
+	if old == nil || sourcesChanged(old, rr) {
+		accessreview.Record(rr, accessreview.User{Name: req.UserInfo.Username, Groups: req.UserInfo.Groups})
+		return nil
+	}
+	// The recorded user can only be changed by retargeting.
+	for _, key := range []string{common.ReviewedUserAnnotation, common.ReviewedGroupsAnnotation} {
+		if value, ok := old.Annotations[key]; ok {
+			if rr.Annotations == nil {
+				rr.Annotations = map[string]string{}
+			}
+			rr.Annotations[key] = value
+		} else {
+			delete(rr.Annotations, key)
+		}
+	}
+	return nil
+}
+
+// +kubebuilder:webhook:path=/validate-utils-simopolis-xyz-v1alpha1-replicatedresource,mutating=false,failurePolicy=fail,sideEffects=None,groups=utils.simopolis.xyz,resources=replicatedresources,verbs=create;update,versions=v1alpha1,name=vreplicatedresource-v1alpha1.kb.io,admissionReviewVersions=v1
+
+// ReplicatedResourceCustomValidator rejects invalid ReplicatedResources,
+// ones that would replicate their destination back to one of their
+// sources, and ones whose sources can't be read by the user creating or
+// retargeting them.
+type ReplicatedResourceCustomValidator struct {
+	Client     client.Client
+	RESTMapper meta.RESTMapper
+	// Replicators holds the kinds with a dedicated replicator, other kinds
+	// must be namespaced kinds known to RESTMapper.
+	Replicators *replicator.Registry
+}
+
+var _ webhook.CustomValidator = &ReplicatedResourceCustomValidator{}
+
+// ValidateCreate implements webhook.CustomValidator.
+func (v *ReplicatedResourceCustomValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	rr, ok := obj.(*utilsv1alpha1.ReplicatedResource)
+	if !ok {
+		return nil, fmt.Errorf("expected a ReplicatedResource object but got %T", obj)
+	}
+	replicatedresourcelog.Info("Validation for ReplicatedResource upon creation", "name", rr.GetName())
+
+	if err := v.validate(ctx, rr, nil); err != nil {
+		return nil, err
+	}
+	return nil, v.reviewAccess(ctx, rr)
+}
+
+// ValidateUpdate implements webhook.CustomValidator.
+func (v *ReplicatedResourceCustomValidator) ValidateUpdate(ctx context.Context, oldObj, newObj runtime.Object) (admission.Warnings, error) {
+	rr, ok := newObj.(*utilsv1alpha1.ReplicatedResource)
+	if !ok {
+		return nil, fmt.Errorf("expected a ReplicatedResource object for the newObj but got %T", newObj)
+	}
+	old, ok := oldObj.(*utilsv1alpha1.ReplicatedResource)
+	if !ok {
+		return nil, fmt.Errorf("expected a ReplicatedResource object for the oldObj but got %T", oldObj)
+	}
+	replicatedresourcelog.Info("Validation for ReplicatedResource upon update", "name", rr.GetName())
+
+	// Let the finalizer be removed even if the spec is no longer valid
+	if !rr.DeletionTimestamp.IsZero() {
+		return nil, nil
+	}
+	if err := v.validate(ctx, rr, old); err != nil {
+		return nil, err
+	}
+	if !sourcesChanged(old, rr) {
+		return nil, nil
+	}
+	return nil, v.reviewAccess(ctx, rr)
+}
+
+// ValidateDelete implements webhook.CustomValidator.
+func (v *ReplicatedResourceCustomValidator) ValidateDelete(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	return nil, nil
+}
+
+// validate returns an Invalid error describing what is wrong with rr, old
+// is the object being updated, if any.
+func (v *ReplicatedResourceCustomValidator) validate(ctx context.Context, rr, old *utilsv1alpha1.ReplicatedResource) error {
+	allErrs := v.validateSources(rr)
+	if old != nil && sourcesChanged(old, rr) && !rr.Spec.Retargetable {
+		allErrs = append(allErrs, field.Forbidden(sourcesPath(rr), "is immutable unless spec.retargetable is set"))
+	}
+	if len(allErrs) == 0 {
+		cycleErr, err := v.validateCycles(ctx, rr)
+		if err != nil {
+			return err
+		}
+		if cycleErr != nil {
+			allErrs = append(allErrs, cycleErr)
+		}
+	}
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(utilsv1alpha1.GroupVersion.WithKind("ReplicatedResource").GroupKind(), rr.Name, allErrs)
+}
+
+// validateSources checks that every source is named, of a kind that can be
+// replicated and isn't the destination.
+func (v *ReplicatedResourceCustomValidator) validateSources(rr *utilsv1alpha1.ReplicatedResource) field.ErrorList {
+	var allErrs field.ErrorList
+	if len(rr.Spec.Sources) > 0 && rr.Spec.Source.Name != "" {
+		allErrs = append(allErrs, field.Forbidden(field.NewPath("spec", "source"), "can't be set together with spec.sources"))
+	}
+
+	sources := rr.Spec.AllSources()
+	destKind := sources[0].GroupVersionKind()
+	for i, source := range sources {
+		path := field.NewPath("spec", "source")
+		if len(rr.Spec.Sources) > 0 {
+			path = field.NewPath("spec", "sources").Index(i)
+		}
+		if source.Name == "" {
+			allErrs = append(allErrs, field.Required(path.Child("name"), ""))
+		}
+		gvk := source.GroupVersionKind()
+		if source.Kind == "" {
+			allErrs = append(allErrs, field.Required(path.Child("kind"), ""))
+		} else if err := v.validateKind(gvk); err != nil {
+			allErrs = append(allErrs, field.Invalid(path.Child("kind"), source.Kind, err.Error()))
+		} else if len(rr.Spec.Sources) > 0 && gvk != corev1.SchemeGroupVersion.WithKind("Secret") && gvk != corev1.SchemeGroupVersion.WithKind("ConfigMap") {
+			allErrs = append(allErrs, field.NotSupported(path.Child("kind"), source.Kind, []string{"Secret", "ConfigMap"}))
+		}
+		if source.ClusterRef == nil && gvk == destKind && source.Namespace == rr.Namespace && source.Name == rr.DestinationName() {
+			allErrs = append(allErrs, field.Invalid(path, source.Name, "is the destination"))
+		}
+	}
+	return allErrs
+}
+
+// validateKind returns an error unless gvk has a replicator or is a
+// namespaced kind that can be replicated generically.
+func (v *ReplicatedResourceCustomValidator) validateKind(gvk schema.GroupVersionKind) error {
+	if _, ok := v.Replicators.Get(gvk); ok {
+		return nil
+	}
+	mapping, err := v.RESTMapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return fmt.Errorf("unknown kind %s", gvk)
+	}
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		return fmt.Errorf("%s is not namespaced", gvk.Kind)
+	}
+	return nil
+}
+
+// replicationNode is an object that is replicated or replicated to.
+type replicationNode struct {
+	kind string
+	key  types.NamespacedName
+}
+
+// validateCycles returns a Forbidden error if the destination of rr is
+// replicated back to one of its sources by other ReplicatedResources.
+func (v *ReplicatedResourceCustomValidator) validateCycles(ctx context.Context, rr *utilsv1alpha1.ReplicatedResource) (*field.Error, error) {
+	replicatedResources := &utilsv1alpha1.ReplicatedResourceList{}
+	if err := v.Client.List(ctx, replicatedResources); err != nil {
+		return nil, err
+	}
+
+	edges := map[replicationNode][]replicationNode{}
+	addEdges := func(item *utilsv1alpha1.ReplicatedResource) replicationNode {
+		sources := item.Spec.AllSources()
+		dest := replicationNode{
+			kind: sources[0].GroupVersionKind().GroupKind().String(),
+			key:  types.NamespacedName{Namespace: item.Namespace, Name: item.DestinationName()},
+		}
+		for _, source := range sources {
+			if source.ClusterRef != nil {
+				// Sources in other clusters can't be replicated to
+				continue
+			}
+			from := replicationNode{
+				kind: source.GroupVersionKind().GroupKind().String(),
+				key:  types.NamespacedName{Namespace: source.Namespace, Name: source.Name},
+			}
+			edges[from] = append(edges[from], dest)
+		}
+		return dest
+	}
+	for i := range replicatedResources.Items {
+		item := &replicatedResources.Items[i]
+		if item.Namespace != rr.Namespace || item.Name != rr.Name {
+			addEdges(item)
+		}
+	}
+	dest := addEdges(rr)
+
+	sources := map[replicationNode]bool{}
+	for _, source := range rr.Spec.AllSources() {
+		if source.ClusterRef != nil {
+			continue
+		}
+		sources[replicationNode{
+			kind: source.GroupVersionKind().GroupKind().String(),
+			key:  types.NamespacedName{Namespace: source.Namespace, Name: source.Name},
+		}] = true
+	}
+
+	visited := map[replicationNode]bool{dest: true}
+	pending := []replicationNode{dest}
+	for len(pending) > 0 {
+		node := pending[0]
+		pending = pending[1:]
+		for _, next := range edges[node] {
+			if sources[next] {
+				return field.Forbidden(sourcesPath(rr), fmt.Sprintf("%s %s is replicated back to %s %s", dest.kind, dest.key, next.kind, next.key)), nil
+			}
+			if !visited[next] {
+				visited[next] = true
+				pending = append(pending, next)
+			}
+		}
+	}
+	return nil, nil
+}
+
+// sourcesPath returns the path of the field holding the sources of rr.
+func sourcesPath(rr *utilsv1alpha1.ReplicatedResource) *field.Path {
+	if len(rr.Spec.Sources) > 0 {
+		return field.NewPath("spec", "sources")
+	}
+	return field.NewPath("spec", "source")
+}
+
+// reviewAccess checks that the user making the request can get every
+// source of rr.
+func (v *ReplicatedResourceCustomValidator) reviewAccess(ctx context.Context, rr *utilsv1alpha1.ReplicatedResource) error {
+	req, err := admission.RequestFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	user := accessreview.User{Name: req.UserInfo.Username, Groups: req.UserInfo.Groups}
+	return accessreview.Review(ctx, v.Client, v.RESTMapper, user, rr)
+}
+
+// oldReplicatedResource returns the object being updated by req, or nil
+// for other operations.
+func oldReplicatedResource(req admission.Request) (*utilsv1alpha1.ReplicatedResource, error) {
+	if req.Operation != admissionv1.Update {
+		return nil, nil
+	}
+	old := &utilsv1alpha1.ReplicatedResource{}
+	if err := json.Unmarshal(req.OldObject.Raw, old); err != nil {
+		return nil, err
+	}
+	return old, nil
+}
+
+// sourcesChanged returns whether rr replicates other sources than old.
+func sourcesChanged(old, rr *utilsv1alpha1.ReplicatedResource) bool {
+	return !equality.Semantic.DeepEqual(old.Spec.AllSources(), rr.Spec.AllSources())
+}
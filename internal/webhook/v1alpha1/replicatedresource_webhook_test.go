@@ -0,0 +1,198 @@
+/*
+Copyright 2021-2022 Russell Sim <russell.sim@gmail.com>
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"encoding/json"
+
+	. "github.com/onsi/ginkgo/v2"
+	. "github.com/onsi/gomega"
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	logf "sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	utilsv1alpha1 "github.com/russell/resource-replication-operator/api/v1alpha1"
+	"github.com/russell/resource-replication-operator/replicator"
+	"github.com/russell/resource-replication-operator/replicator/common"
+)
+
+var _ = Describe("ReplicatedResource Webhook", func() {
+	var (
+		obj       *utilsv1alpha1.ReplicatedResource
+		validator ReplicatedResourceCustomValidator
+		defaulter ReplicatedResourceCustomDefaulter
+	)
+
+	admissionContext := func(operation admissionv1.Operation, user authenticationv1.UserInfo, old runtime.Object) {
+		req := admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+			Operation: operation,
+			UserInfo:  user,
+		}}
+		if old != nil {
+			raw, err := json.Marshal(old)
+			Expect(err).NotTo(HaveOccurred())
+			req.OldObject = runtime.RawExtension{Raw: raw}
+		}
+		ctx = admission.NewContextWithRequest(ctx, req)
+	}
+
+	admin := authenticationv1.UserInfo{Username: "admin", Groups: []string{"system:masters"}}
+	developer := authenticationv1.UserInfo{Username: "developer", Groups: []string{"system:authenticated"}}
+
+	BeforeEach(func() {
+		obj = &utilsv1alpha1.ReplicatedResource{
+			ObjectMeta: metav1.ObjectMeta{Name: "replicated", Namespace: "apps"},
+			Spec: utilsv1alpha1.ReplicatedResourceSpec{
+				Source: utilsv1alpha1.ReplicatedResourceSource{
+					Namespace: "certificates",
+					Name:      "wildcard-tls",
+					Kind:      "Secret",
+				},
+			},
+		}
+		validator = ReplicatedResourceCustomValidator{
+			Client:      k8sClient,
+			RESTMapper:  restMapper,
+			Replicators: replicator.NewRegistry(logf.Log),
+		}
+		defaulter = ReplicatedResourceCustomDefaulter{}
+	})
+
+	Context("When creating ReplicatedResource under Defaulting Webhook", func() {
+		It("Should default the source namespace", func() {
+			obj.Spec.Source.Namespace = ""
+			admissionContext(admissionv1.Create, admin, nil)
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+			Expect(obj.Spec.Source.Namespace).To(Equal("apps"))
+		})
+
+		It("Should record the requesting user", func() {
+			admissionContext(admissionv1.Create, admin, nil)
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+			Expect(obj.Annotations).To(HaveKeyWithValue(common.ReviewedUserAnnotation, "admin"))
+			Expect(obj.Annotations).To(HaveKeyWithValue(common.ReviewedGroupsAnnotation, "system:masters"))
+		})
+
+		It("Should keep the recorded user unless the source changes", func() {
+			old := obj.DeepCopy()
+			old.Annotations = map[string]string{common.ReviewedUserAnnotation: "admin"}
+			obj.Annotations = map[string]string{common.ReviewedUserAnnotation: "someone-else"}
+
+			admissionContext(admissionv1.Update, developer, old)
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+			Expect(obj.Annotations).To(HaveKeyWithValue(common.ReviewedUserAnnotation, "admin"))
+
+			obj.Spec.Source.Name = "other-tls"
+			Expect(defaulter.Default(ctx, obj)).To(Succeed())
+			Expect(obj.Annotations).To(HaveKeyWithValue(common.ReviewedUserAnnotation, "developer"))
+		})
+	})
+
+	Context("When creating or updating ReplicatedResource under Validating Webhook", func() {
+		It("Should allow users that can get the source", func() {
+			admissionContext(admissionv1.Create, admin, nil)
+			Expect(validator.ValidateCreate(ctx, obj)).Error().NotTo(HaveOccurred())
+		})
+
+		It("Should deny users that can't get the source", func() {
+			admissionContext(admissionv1.Create, developer, nil)
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(ContainSubstring("User developer can't get secrets certificates/wildcard-tls")))
+		})
+
+		It("Should only review updates that change the source", func() {
+			old := obj.DeepCopy()
+			admissionContext(admissionv1.Update, developer, old)
+			obj.Spec.Destination.Name = "renamed"
+			Expect(validator.ValidateUpdate(ctx, old, obj)).Error().NotTo(HaveOccurred())
+
+			obj.Spec.Retargetable = true
+			obj.Spec.Source.Name = "other-tls"
+			Expect(validator.ValidateUpdate(ctx, old, obj)).Error().To(MatchError(ContainSubstring("User developer can't get")))
+		})
+
+		It("Should deny changing the source unless it is retargetable", func() {
+			old := obj.DeepCopy()
+			admissionContext(admissionv1.Update, admin, old)
+			obj.Spec.Source.Name = "other-tls"
+			Expect(validator.ValidateUpdate(ctx, old, obj)).Error().To(MatchError(ContainSubstring("spec.source: Forbidden")))
+
+			obj.Spec.Retargetable = true
+			Expect(validator.ValidateUpdate(ctx, old, obj)).Error().NotTo(HaveOccurred())
+		})
+
+		It("Should deny sources without a name", func() {
+			admissionContext(admissionv1.Create, admin, nil)
+			obj.Spec.Source.Name = ""
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(ContainSubstring("spec.source.name: Required value")))
+		})
+
+		It("Should deny unknown and cluster scoped kinds", func() {
+			admissionContext(admissionv1.Create, admin, nil)
+			obj.Spec.Source.APIVersion = "example.com/v1"
+			obj.Spec.Source.Kind = "Widget"
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(ContainSubstring("unknown kind")))
+
+			obj.Spec.Source.APIVersion = "rbac.authorization.k8s.io/v1"
+			obj.Spec.Source.Kind = "ClusterRole"
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(ContainSubstring("is not namespaced")))
+		})
+
+		It("Should deny replicating the destination onto itself", func() {
+			admissionContext(admissionv1.Create, admin, nil)
+			obj.Spec.Source = utilsv1alpha1.ReplicatedResourceSource{Namespace: "apps", Name: "replicated", Kind: "Secret"}
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(ContainSubstring("is the destination")))
+		})
+
+		It("Should deny replication cycles", func() {
+			Expect(k8sClient.Create(ctx, &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "cycle"}})).To(Succeed())
+			existing := &utilsv1alpha1.ReplicatedResource{
+				ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "cycle"},
+				Spec: utilsv1alpha1.ReplicatedResourceSpec{
+					Source: utilsv1alpha1.ReplicatedResourceSource{Namespace: "cycle", Name: "a", Kind: "Secret"},
+				},
+			}
+			Expect(k8sClient.Create(ctx, existing)).To(Succeed())
+
+			admissionContext(admissionv1.Create, admin, nil)
+			obj = &utilsv1alpha1.ReplicatedResource{
+				ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "cycle"},
+				Spec: utilsv1alpha1.ReplicatedResourceSpec{
+					Source: utilsv1alpha1.ReplicatedResourceSource{Namespace: "cycle", Name: "b", Kind: "Secret"},
+				},
+			}
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(ContainSubstring("is replicated back to Secret cycle/b")))
+		})
+
+		It("Should review access to the kubeconfig of sources in other clusters", func() {
+			obj.Spec.Source = utilsv1alpha1.ReplicatedResourceSource{
+				Namespace:  "apps",
+				Name:       "replicated",
+				Kind:       "Secret",
+				ClusterRef: &utilsv1alpha1.SourceClusterReference{Name: "hub-kubeconfig"},
+			}
+			admissionContext(admissionv1.Create, admin, nil)
+			Expect(validator.ValidateCreate(ctx, obj)).Error().NotTo(HaveOccurred())
+
+			admissionContext(admissionv1.Create, developer, nil)
+			Expect(validator.ValidateCreate(ctx, obj)).Error().To(MatchError(ContainSubstring("User developer can't get secrets apps/hub-kubeconfig")))
+		})
+	})
+})
@@ -4,4 +4,58 @@ package common
 const (
 	ReplicatedAtAnnotation          = "replicated-resource.simopolis.xyz/updated"
 	ReplicatedFromVersionAnnotation = "replicated-resource.simopolis.xyz/version"
+	// ReplicatedHashAnnotation is the SHA-256 of the replicated content.
+	ReplicatedHashAnnotation = "replicated-resource.simopolis.xyz/hash"
+	// ManagedLabelsAnnotation and ManagedAnnotationsAnnotation list the
+	// label and annotation keys set on a destination by the replication,
+	// so that they can be removed once they are no longer wanted.
+	ManagedLabelsAnnotation      = "replicated-resource.simopolis.xyz/managed-labels"
+	ManagedAnnotationsAnnotation = "replicated-resource.simopolis.xyz/managed-annotations"
+	// AllowedNamespacesAnnotation, AllowedNamespacesRegexAnnotation and
+	// AllowedNamespaceSelectorAnnotation are set on a source to allow its
+	// replication to other namespaces, by a comma separated list of
+	// names, a regular expression matching the whole name or a label
+	// selector.
+	AllowedNamespacesAnnotation        = "replicated-resource.simopolis.xyz/allowed-namespaces"
+	AllowedNamespacesRegexAnnotation   = "replicated-resource.simopolis.xyz/allowed-namespaces-regex"
+	AllowedNamespaceSelectorAnnotation = "replicated-resource.simopolis.xyz/allowed-namespace-selector"
+	// ReviewedUserAnnotation and ReviewedGroupsAnnotation record the user,
+	// and their comma separated groups, that was checked to be able to
+	// read the sources of a ReplicatedResource.
+	ReviewedUserAnnotation   = "replicated-resource.simopolis.xyz/reviewed-user"
+	ReviewedGroupsAnnotation = "replicated-resource.simopolis.xyz/reviewed-groups"
+	// AdoptedAnnotation records when a destination that already existed
+	// was adopted.
+	AdoptedAnnotation = "replicated-resource.simopolis.xyz/adopted"
+	// RemoteOwnerAnnotation identifies the owner of a destination that
+	// owner references can't refer to, in a remote cluster or another
+	// namespace, as Kind/namespace/name.
+	RemoteOwnerAnnotation = "replicated-resource.simopolis.xyz/remote-owner"
+	// ReplicateToAnnotation lists, comma separated, the namespaces an
+	// annotated Secret or ConfigMap is copied to, and
+	// ReplicateToSelectorAnnotation selects them with a label selector.
+	ReplicateToAnnotation         = "replicated-resource.simopolis.xyz/replicate-to"
+	ReplicateToSelectorAnnotation = "replicated-resource.simopolis.xyz/replicate-to-selector"
+	// AcceptFromAnnotation is set on a Namespace to accept the copies
+	// of annotated Secrets and ConfigMaps from a comma separated list of
+	// namespaces, or from any namespace with "*".
+	AcceptFromAnnotation = "replicated-resource.simopolis.xyz/accept-from"
+	// ReplicationStatusAnnotation summarises, as JSON, the namespaces an
+	// annotated Secret or ConfigMap was copied to and the failures.
+	ReplicationStatusAnnotation = "replicated-resource.simopolis.xyz/replication-status"
+	// DeleteWithSourceAnnotation marks the copies of an annotated source
+	// that are deleted along with it, which kubed and Reflector do.
+	DeleteWithSourceAnnotation = "replicated-resource.simopolis.xyz/delete-with-source"
+	// ChecksumAnnotation is patched into the pod template of the workloads
+	// selected by spec.rollout, as the content hash of the destination, to
+	// restart them when it changes.
+	ChecksumAnnotation = "replicated-resource.simopolis.xyz/checksum"
 )
+
+// AdoptLabel is set on an existing object, to the name of the
+// ReplicatedResource or ClusterReplicatedResource, to let the
+// AdoptIfMatchingLabel conflict policy adopt it.
+const AdoptLabel = "replicated-resource.simopolis.xyz/adopt"
+
+// AnnotationPrefix is the prefix of every annotation used by this Controller
+const AnnotationPrefix = "replicated-resource.simopolis.xyz/"
@@ -0,0 +1,33 @@
+package common
+
+// Annotations of kubed and Reflector that are honoured when the manager
+// runs with --compatibility-annotations, so that sources annotated for those
+// tools don't need to be annotated again.
+const (
+	// KubedSyncAnnotation copies a Secret or ConfigMap to every other
+	// namespace, or to the namespaces matching its value, a label selector.
+	KubedSyncAnnotation = "kubed.appscode.com/sync"
+	// ReflectionAllowedAnnotation, when "true", allows a source to be
+	// replicated to the namespaces matching
+	// ReflectionAllowedNamespacesAnnotation, a comma separated list of
+	// regular expressions, or to every namespace if it isn't set.
+	ReflectionAllowedAnnotation           = "reflector.v1.k8s.emberstack.com/reflection-allowed"
+	ReflectionAllowedNamespacesAnnotation = "reflector.v1.k8s.emberstack.com/reflection-allowed-namespaces"
+	// ReflectionAutoEnabledAnnotation, when "true", copies a Secret or
+	// ConfigMap to the allowed namespaces matching
+	// ReflectionAutoNamespacesAnnotation, or to every allowed namespace if
+	// it isn't set.
+	ReflectionAutoEnabledAnnotation    = "reflector.v1.k8s.emberstack.com/reflection-auto-enabled"
+	ReflectionAutoNamespacesAnnotation = "reflector.v1.k8s.emberstack.com/reflection-auto-namespaces"
+)
+
+// PushAnnotations are the kubed and Reflector annotations that ask for a
+// source to be replicated. They are never copied, as kubed and Reflector
+// don't copy them either, so that copies aren't replicated again.
+var PushAnnotations = []string{
+	KubedSyncAnnotation,
+	ReflectionAllowedAnnotation,
+	ReflectionAllowedNamespacesAnnotation,
+	ReflectionAutoEnabledAnnotation,
+	ReflectionAutoNamespacesAnnotation,
+}
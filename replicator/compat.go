@@ -0,0 +1,71 @@
+/*
+   Copyright 2021-2022 Russell Sim <russell.sim@gmail.com>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package replicator
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/russell/resource-replication-operator/replicator/common"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ReflectionAllowed reports whether the Reflector annotations of source
+// allow its replication to namespace.
+func ReflectionAllowed(source client.Object, namespace string) (bool, error) {
+	annotations := source.GetAnnotations()
+	if !strings.EqualFold(annotations[common.ReflectionAllowedAnnotation], "true") {
+		return false, nil
+	}
+	return matchesPatterns(common.ReflectionAllowedNamespacesAnnotation, annotations[common.ReflectionAllowedNamespacesAnnotation], namespace)
+}
+
+// ReflectionAuto reports whether the Reflector annotations of source ask
+// for it to be copied to namespace.
+func ReflectionAuto(source client.Object, namespace string) (bool, error) {
+	annotations := source.GetAnnotations()
+	if !strings.EqualFold(annotations[common.ReflectionAutoEnabledAnnotation], "true") {
+		return false, nil
+	}
+	if allowed, err := ReflectionAllowed(source, namespace); !allowed || err != nil {
+		return false, err
+	}
+	return matchesPatterns(common.ReflectionAutoNamespacesAnnotation, annotations[common.ReflectionAutoNamespacesAnnotation], namespace)
+}
+
+// matchesPatterns reports whether namespace is matched by one of the comma
+// separated regular expressions of the annotation named annotation, each
+// matching the whole name. Every namespace matches an empty list.
+func matchesPatterns(annotation, patterns, namespace string) (bool, error) {
+	if strings.TrimSpace(patterns) == "" {
+		return true, nil
+	}
+	for _, pattern := range strings.Split(patterns, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile(fmt.Sprintf("^(?:%s)$", pattern))
+		if err != nil {
+			return false, fmt.Errorf("Invalid annotation %s: %w", annotation, err)
+		}
+		if re.MatchString(namespace) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
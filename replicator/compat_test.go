@@ -0,0 +1,110 @@
+/*
+   Copyright 2021-2022 Russell Sim <russell.sim@gmail.com>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package replicator
+
+import (
+	"testing"
+
+	"github.com/russell/resource-replication-operator/replicator/common"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestReflectionAuto(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		namespace   string
+		want        bool
+		wantErr     bool
+	}{
+		{
+			name:        "not allowed",
+			annotations: map[string]string{common.ReflectionAutoEnabledAnnotation: "true"},
+			namespace:   "payments",
+		},
+		{
+			name:        "not enabled",
+			annotations: map[string]string{common.ReflectionAllowedAnnotation: "true"},
+			namespace:   "payments",
+		},
+		{
+			name: "every allowed namespace",
+			annotations: map[string]string{
+				common.ReflectionAllowedAnnotation:     "true",
+				common.ReflectionAutoEnabledAnnotation: "true",
+			},
+			namespace: "payments",
+			want:      true,
+		},
+		{
+			name: "auto namespaces",
+			annotations: map[string]string{
+				common.ReflectionAllowedAnnotation:        "true",
+				common.ReflectionAutoEnabledAnnotation:    "true",
+				common.ReflectionAutoNamespacesAnnotation: "orders, pay.*",
+			},
+			namespace: "payments",
+			want:      true,
+		},
+		{
+			name: "auto namespaces match the whole name",
+			annotations: map[string]string{
+				common.ReflectionAllowedAnnotation:        "true",
+				common.ReflectionAutoEnabledAnnotation:    "true",
+				common.ReflectionAutoNamespacesAnnotation: "pay",
+			},
+			namespace: "payments",
+		},
+		{
+			name: "auto namespace isn't allowed",
+			annotations: map[string]string{
+				common.ReflectionAllowedAnnotation:           "true",
+				common.ReflectionAllowedNamespacesAnnotation: "orders",
+				common.ReflectionAutoEnabledAnnotation:       "true",
+				common.ReflectionAutoNamespacesAnnotation:    "pay.*",
+			},
+			namespace: "payments",
+		},
+		{
+			name: "invalid pattern",
+			annotations: map[string]string{
+				common.ReflectionAllowedAnnotation:        "true",
+				common.ReflectionAutoEnabledAnnotation:    "true",
+				common.ReflectionAutoNamespacesAnnotation: "pay(",
+			},
+			namespace: "payments",
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+				Name:        "wildcard-tls",
+				Namespace:   "certificates",
+				Annotations: tt.annotations,
+			}}
+			got, err := ReflectionAuto(source, tt.namespace)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ReflectionAuto() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ReflectionAuto() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
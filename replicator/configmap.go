@@ -0,0 +1,91 @@
+/*
+   Copyright 2021-2022 Russell Sim <russell.sim@gmail.com>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package replicator
+
+import (
+	"context"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// ConfigMapReplicator replicates the data and binary data of ConfigMaps.
+type ConfigMapReplicator struct {
+	Log logr.Logger
+}
+
+func (r *ConfigMapReplicator) NewObject() client.Object {
+	return &corev1.ConfigMap{}
+}
+
+func (r *ConfigMapReplicator) Fetch(ctx context.Context, c client.Client, key types.NamespacedName) (client.Object, error) {
+	source := &corev1.ConfigMap{}
+	if err := fetch(ctx, c, key, source, "configmap"); err != nil {
+		return nil, err
+	}
+	return source, nil
+}
+
+func (r *ConfigMapReplicator) Desired(rep *Replication, source client.Object) (client.Object, error) {
+	configMap := source.(*corev1.ConfigMap)
+	mapping, err := keyMapping(append(mapKeys(configMap.Data), mapKeys(configMap.BinaryData)...), rep.Transform.Keys)
+	if err != nil {
+		return nil, err
+	}
+	data := transformKeys(configMap.Data, mapping)
+	binaryData := transformKeys(configMap.BinaryData, mapping)
+	sourceData := make(map[string]string, len(configMap.Data)+len(configMap.BinaryData))
+	for key, value := range configMap.Data {
+		sourceData[key] = value
+	}
+	for key, value := range configMap.BinaryData {
+		sourceData[key] = string(value)
+	}
+	rendered, err := renderTemplates(rep.Transform.Template, configMap, sourceData)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range rendered {
+		if data == nil {
+			data = map[string]string{}
+		}
+		data[key] = value
+		delete(binaryData, key)
+	}
+	return &corev1.ConfigMap{
+		ObjectMeta: desiredMeta(rep, source, data, binaryData),
+		Data:       data,
+		BinaryData: binaryData,
+	}, nil
+}
+
+func (r *ConfigMapReplicator) Apply(ctx context.Context, c client.Client, rep *Replication, desired client.Object) (Result, error) {
+	want := desired.(*corev1.ConfigMap)
+	dest := &corev1.ConfigMap{}
+	dest.Name = want.Name
+	dest.Namespace = want.Namespace
+
+	return createOrUpdate(ctx, c, rep, want, dest, func() bool {
+		return equality.Semantic.DeepEqual(dest.Data, want.Data) && equality.Semantic.DeepEqual(dest.BinaryData, want.BinaryData)
+	}, func() {
+		r.Log.Info("Updating configmap", "destination", client.ObjectKeyFromObject(dest).String())
+		dest.Data = want.Data
+		dest.BinaryData = want.BinaryData
+	})
+}
@@ -0,0 +1,85 @@
+/*
+   Copyright 2021-2022 Russell Sim <russell.sim@gmail.com>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package replicator
+
+import (
+	"context"
+	"fmt"
+	"github.com/russell/resource-replication-operator/replicator/common"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"regexp"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+)
+
+// authorize returns a Forbidden Error unless source allows replication to
+// namespace with one of its allowed namespaces annotations, or its Reflector
+// annotations when compatibility is set. Sources can always be replicated
+// within their own namespace.
+func authorize(ctx context.Context, c client.Client, source client.Object, namespace string, compatibility bool) error {
+	if source.GetNamespace() == namespace {
+		return nil
+	}
+	annotations := source.GetAnnotations()
+	sourceKey := client.ObjectKeyFromObject(source)
+
+	if allowed, ok := annotations[common.AllowedNamespacesAnnotation]; ok {
+		for _, name := range strings.Split(allowed, ",") {
+			if strings.TrimSpace(name) == namespace {
+				return nil
+			}
+		}
+	}
+
+	if pattern, ok := annotations[common.AllowedNamespacesRegexAnnotation]; ok {
+		re, err := regexp.Compile(fmt.Sprintf("^(?:%s)$", pattern))
+		if err != nil {
+			return &Error{Reason: "Forbidden", Object: source, Message: fmt.Sprintf("Invalid annotation %s on %s: %s", common.AllowedNamespacesRegexAnnotation, sourceKey, err)}
+		}
+		if re.MatchString(namespace) {
+			return nil
+		}
+	}
+
+	if selector, ok := annotations[common.AllowedNamespaceSelectorAnnotation]; ok {
+		parsed, err := labels.Parse(selector)
+		if err != nil {
+			return &Error{Reason: "Forbidden", Object: source, Message: fmt.Sprintf("Invalid annotation %s on %s: %s", common.AllowedNamespaceSelectorAnnotation, sourceKey, err)}
+		}
+		ns := &corev1.Namespace{}
+		if err := c.Get(ctx, types.NamespacedName{Name: namespace}, ns); err != nil {
+			return err
+		}
+		if parsed.Matches(labels.Set(ns.Labels)) {
+			return nil
+		}
+	}
+
+	if compatibility {
+		allowed, err := ReflectionAllowed(source, namespace)
+		if err != nil {
+			return &Error{Reason: "Forbidden", Object: source, Message: fmt.Sprintf("%s on %s", err, sourceKey)}
+		}
+		if allowed {
+			return nil
+		}
+	}
+
+	return &Error{Reason: "Forbidden", Object: source, Message: fmt.Sprintf("Source %s does not allow replication to namespace %s", sourceKey, namespace)}
+}
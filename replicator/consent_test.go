@@ -0,0 +1,132 @@
+/*
+   Copyright 2021-2022 Russell Sim <russell.sim@gmail.com>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package replicator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/russell/resource-replication-operator/replicator/common"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestAuthorize(t *testing.T) {
+	c := fake.NewClientBuilder().WithObjects(
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "payments", Labels: map[string]string{"team": "payments"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "sandbox"}},
+	).Build()
+
+	tests := []struct {
+		name          string
+		annotations   map[string]string
+		namespace     string
+		compatibility bool
+		reason        string
+	}{
+		{
+			name:      "same namespace",
+			namespace: "certificates",
+		},
+		{
+			name:      "no annotation",
+			namespace: "payments",
+			reason:    "Forbidden",
+		},
+		{
+			name:        "list",
+			annotations: map[string]string{common.AllowedNamespacesAnnotation: "orders, payments"},
+			namespace:   "payments",
+		},
+		{
+			name:        "not in list",
+			annotations: map[string]string{common.AllowedNamespacesAnnotation: "orders,payments"},
+			namespace:   "sandbox",
+			reason:      "Forbidden",
+		},
+		{
+			name:        "regex",
+			annotations: map[string]string{common.AllowedNamespacesRegexAnnotation: "pay.*"},
+			namespace:   "payments",
+		},
+		{
+			name:        "regex matches the whole name",
+			annotations: map[string]string{common.AllowedNamespacesRegexAnnotation: "pay"},
+			namespace:   "payments",
+			reason:      "Forbidden",
+		},
+		{
+			name:        "invalid regex",
+			annotations: map[string]string{common.AllowedNamespacesRegexAnnotation: "pay("},
+			namespace:   "payments",
+			reason:      "Forbidden",
+		},
+		{
+			name:        "selector",
+			annotations: map[string]string{common.AllowedNamespaceSelectorAnnotation: "team=payments"},
+			namespace:   "payments",
+		},
+		{
+			name:        "selector doesn't match",
+			annotations: map[string]string{common.AllowedNamespaceSelectorAnnotation: "team=payments"},
+			namespace:   "sandbox",
+			reason:      "Forbidden",
+		},
+		{
+			name:          "reflection allowed",
+			annotations:   map[string]string{common.ReflectionAllowedAnnotation: "true", common.ReflectionAllowedNamespacesAnnotation: "orders,pay.*"},
+			namespace:     "payments",
+			compatibility: true,
+		},
+		{
+			name:          "reflection allowed to every namespace",
+			annotations:   map[string]string{common.ReflectionAllowedAnnotation: "True"},
+			namespace:     "sandbox",
+			compatibility: true,
+		},
+		{
+			name:          "reflection not allowed to namespace",
+			annotations:   map[string]string{common.ReflectionAllowedAnnotation: "true", common.ReflectionAllowedNamespacesAnnotation: "pay.*"},
+			namespace:     "sandbox",
+			compatibility: true,
+			reason:        "Forbidden",
+		},
+		{
+			name:        "reflection ignored without compatibility",
+			annotations: map[string]string{common.ReflectionAllowedAnnotation: "true"},
+			namespace:   "payments",
+			reason:      "Forbidden",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+				Name:        "wildcard-tls",
+				Namespace:   "certificates",
+				Annotations: tt.annotations,
+			}}
+			err := authorize(context.Background(), c, source, tt.namespace, tt.compatibility)
+			if tt.reason == "" && err != nil {
+				t.Errorf("authorize() error = %v", err)
+			}
+			if tt.reason != "" && (err == nil || ReasonFor(err) != tt.reason) {
+				t.Errorf("authorize() error = %v, want reason %s", err, tt.reason)
+			}
+		})
+	}
+}
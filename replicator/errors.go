@@ -0,0 +1,51 @@
+/*
+   Copyright 2021-2022 Russell Sim <russell.sim@gmail.com>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package replicator
+
+import (
+	"errors"
+	"fmt"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Error is a replication error with a CamelCase reason, which is used as
+// the reason of the status condition that reports it.
+type Error struct {
+	Reason  string
+	Message string
+	// Object is the object that caused the error, if any.
+	Object client.Object
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// newError returns an Error with a formatted message.
+func newError(reason, format string, args ...interface{}) *Error {
+	return &Error{Reason: reason, Message: fmt.Sprintf(format, args...)}
+}
+
+// ReasonFor returns the reason of err if it is, or wraps, an Error and
+// "Error" otherwise.
+func ReasonFor(err error) string {
+	var replicationErr *Error
+	if errors.As(err, &replicationErr) {
+		return replicationErr.Reason
+	}
+	return "Error"
+}
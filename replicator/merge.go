@@ -0,0 +1,131 @@
+/*
+   Copyright 2021-2022 Russell Sim <russell.sim@gmail.com>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package replicator
+
+import (
+	"context"
+	"fmt"
+	utilsv1alpha1 "github.com/russell/resource-replication-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+	"unicode/utf8"
+)
+
+// MergeSource is a Secret or ConfigMap merged by Merge.
+type MergeSource struct {
+	Replicator Replicator
+	Key        types.NamespacedName
+	// KeyPrefix is prepended to the keys of the source.
+	KeyPrefix string
+	// Client reads the source, defaults to the client passed to Merge.
+	Client client.Client
+	// CompatibilityAnnotations also accepts the Reflector annotations of
+	// the source as consent, see common.ReflectionAllowedAnnotation.
+	CompatibilityAnnotations bool
+}
+
+// Merge fetches every source and returns an object of the kind of the first
+// one, with its metadata and the data of every source, along with the
+// fetched sources. The resource version
+// of the merged object lists the resource versions of the sources so that
+// it changes whenever one of them does. Every source must allow replication
+// to namespace, the namespace of the destination.
+func Merge(ctx context.Context, c client.Client, namespace string, sources []MergeSource, policy utilsv1alpha1.KeyConflictPolicy) (client.Object, []client.Object, error) {
+	if len(sources) == 0 {
+		return nil, nil, fmt.Errorf("No sources to merge")
+	}
+
+	var merged client.Object
+	data := map[string][]byte{}
+	origins := map[string]types.NamespacedName{}
+	versions := make([]string, 0, len(sources))
+	fetched := make([]client.Object, 0, len(sources))
+	for _, source := range sources {
+		sourceClient := c
+		if source.Client != nil {
+			sourceClient = source.Client
+		}
+		obj, err := source.Replicator.Fetch(ctx, sourceClient, source.Key)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := authorize(ctx, c, obj, namespace, source.CompatibilityAnnotations); err != nil {
+			return nil, nil, err
+		}
+		if merged == nil {
+			merged = obj.DeepCopyObject().(client.Object)
+		}
+		versions = append(versions, obj.GetResourceVersion())
+		fetched = append(fetched, obj)
+
+		sourceData, err := mergeableData(obj)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, key := range mapKeys(sourceData) {
+			destKey := source.KeyPrefix + key
+			if origin, ok := origins[destKey]; ok {
+				switch policy {
+				case utilsv1alpha1.KeyConflictFirstWins:
+					continue
+				case utilsv1alpha1.KeyConflictLastWins:
+				default:
+					return nil, nil, newError("KeyConflict", "Key %q is in both %s and %s", destKey, origin, source.Key)
+				}
+			}
+			data[destKey] = sourceData[key]
+			origins[destKey] = source.Key
+		}
+	}
+
+	merged.SetResourceVersion(strings.Join(versions, ","))
+	switch obj := merged.(type) {
+	case *corev1.Secret:
+		obj.Data = data
+	case *corev1.ConfigMap:
+		obj.Data = map[string]string{}
+		obj.BinaryData = map[string][]byte{}
+		for key, value := range data {
+			if utf8.Valid(value) {
+				obj.Data[key] = string(value)
+			} else {
+				obj.BinaryData[key] = value
+			}
+		}
+	}
+	return merged, fetched, nil
+}
+
+// mergeableData returns the data of a Secret or ConfigMap.
+func mergeableData(obj client.Object) (map[string][]byte, error) {
+	switch source := obj.(type) {
+	case *corev1.Secret:
+		return source.Data, nil
+	case *corev1.ConfigMap:
+		data := make(map[string][]byte, len(source.Data)+len(source.BinaryData))
+		for key, value := range source.Data {
+			data[key] = []byte(value)
+		}
+		for key, value := range source.BinaryData {
+			data[key] = value
+		}
+		return data, nil
+	}
+	return nil, newError("InvalidSource", "Could not merge %s, only Secrets and ConfigMaps can be merged", client.ObjectKeyFromObject(obj))
+}
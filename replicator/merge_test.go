@@ -0,0 +1,99 @@
+/*
+   Copyright 2021-2022 Russell Sim <russell.sim@gmail.com>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package replicator
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	utilsv1alpha1 "github.com/russell/resource-replication-operator/api/v1alpha1"
+	"github.com/russell/resource-replication-operator/replicator/common"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestMerge(t *testing.T) {
+	allowed := map[string]string{common.AllowedNamespacesAnnotation: "apps"}
+	c := fake.NewClientBuilder().WithObjects(
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "databases", Labels: map[string]string{"team": "data"}, Annotations: allowed},
+			Data:       map[string][]byte{"user": []byte("app"), "password": []byte("hunter2")},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "api", Namespace: "vendors", Annotations: allowed},
+			Data:       map[string][]byte{"password": []byte("key")},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: "ca", Namespace: "certificates", Annotations: allowed},
+			Data:       map[string]string{"ca.crt": "bundle"},
+		},
+	).Build()
+	sources := []MergeSource{
+		{Replicator: &SecretReplicator{}, Key: types.NamespacedName{Namespace: "databases", Name: "db"}},
+		{Replicator: &SecretReplicator{}, Key: types.NamespacedName{Namespace: "vendors", Name: "api"}},
+		{Replicator: &ConfigMapReplicator{}, Key: types.NamespacedName{Namespace: "certificates", Name: "ca"}, KeyPrefix: "ca-"},
+	}
+
+	tests := []struct {
+		name   string
+		policy utilsv1alpha1.KeyConflictPolicy
+		data   map[string][]byte
+		reason string
+	}{
+		{
+			name:   "first wins",
+			policy: utilsv1alpha1.KeyConflictFirstWins,
+			data:   map[string][]byte{"user": []byte("app"), "password": []byte("hunter2"), "ca-ca.crt": []byte("bundle")},
+		},
+		{
+			name:   "last wins",
+			policy: utilsv1alpha1.KeyConflictLastWins,
+			data:   map[string][]byte{"user": []byte("app"), "password": []byte("key"), "ca-ca.crt": []byte("bundle")},
+		},
+		{
+			name:   "error by default",
+			reason: "KeyConflict",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged, fetched, err := Merge(context.Background(), c, "apps", sources, tt.policy)
+			if tt.reason != "" {
+				if err == nil || ReasonFor(err) != tt.reason {
+					t.Fatalf("Merge() error = %v, want reason %s", err, tt.reason)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Merge() error = %v", err)
+			}
+			secret := merged.(*corev1.Secret)
+			if !reflect.DeepEqual(secret.Data, tt.data) {
+				t.Errorf("Merge() data = %v, want %v", secret.Data, tt.data)
+			}
+			if secret.Labels["team"] != "data" {
+				t.Errorf("Merge() labels = %v, want the labels of the first source", secret.Labels)
+			}
+			if len(fetched) != len(sources) {
+				t.Errorf("Merge() returned %d sources, want %d", len(fetched), len(sources))
+			}
+		})
+	}
+}
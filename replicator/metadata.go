@@ -0,0 +1,129 @@
+/*
+   Copyright 2021-2022 Russell Sim <russell.sim@gmail.com>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package replicator
+
+import (
+	utilsv1alpha1 "github.com/russell/resource-replication-operator/api/v1alpha1"
+	"github.com/russell/resource-replication-operator/replicator/common"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// replicationAnnotations are only updated together with the replicated
+// content.
+var replicationAnnotations = []string{
+	common.ReplicatedAtAnnotation,
+	common.ReplicatedFromVersionAnnotation,
+	common.ReplicatedHashAnnotation,
+}
+
+// desiredLabels returns the source labels selected by rep.SourceMetadata
+// overlaid with rep.Labels.
+func desiredLabels(rep *Replication, source client.Object) map[string]string {
+	labels := sourceMetadata(source.GetLabels(), rep.SourceMetadata)
+	for k, v := range rep.Labels {
+		labels[k] = v
+	}
+	return labels
+}
+
+// desiredAnnotations returns the source annotations selected by
+// rep.SourceMetadata overlaid with rep.Annotations. The annotations of this
+// controller, the kubed and Reflector push annotations and kubectl's last
+// applied configuration are never copied.
+func desiredAnnotations(rep *Replication, source client.Object) map[string]string {
+	annotations := sourceMetadata(source.GetAnnotations(), rep.SourceMetadata)
+	for k := range annotations {
+		if strings.HasPrefix(k, common.AnnotationPrefix) || slices.Contains(common.PushAnnotations, k) || k == corev1.LastAppliedConfigAnnotation {
+			delete(annotations, k)
+		}
+	}
+	for k, v := range rep.Annotations {
+		annotations[k] = v
+	}
+	return annotations
+}
+
+// sourceMetadata returns the entries of metadata selected by policy.
+func sourceMetadata(metadata map[string]string, policy utilsv1alpha1.SourceMetadata) map[string]string {
+	selected := make(map[string]string)
+	for k, v := range metadata {
+		switch policy.Policy {
+		case utilsv1alpha1.SourceMetadataAll:
+			selected[k] = v
+		case utilsv1alpha1.SourceMetadataAllowList:
+			for _, prefix := range policy.Prefixes {
+				if strings.HasPrefix(k, prefix) {
+					selected[k] = v
+					break
+				}
+			}
+		}
+	}
+	return selected
+}
+
+// syncMetadata sets the labels and annotations of desired on dest, and
+// removes those set by a previous replication that are no longer desired.
+// The replication annotations are left to createOrUpdate.
+func syncMetadata(dest, desired client.Object) {
+	labels := dest.GetLabels()
+	if labels == nil {
+		labels = make(map[string]string)
+	}
+	annotations := dest.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+
+	wantAnnotations := make(map[string]string)
+	for k, v := range desired.GetAnnotations() {
+		if !slices.Contains(replicationAnnotations, k) {
+			wantAnnotations[k] = v
+		}
+	}
+
+	syncManaged(labels, desired.GetLabels(), annotations, common.ManagedLabelsAnnotation)
+	syncManaged(annotations, wantAnnotations, annotations, common.ManagedAnnotationsAnnotation)
+	dest.SetLabels(labels)
+	dest.SetAnnotations(annotations)
+}
+
+// syncManaged sets want on current and removes the keys recorded in the
+// managed annotation that aren't in want, then records the keys of want.
+func syncManaged(current, want, annotations map[string]string, managed string) {
+	for _, k := range strings.Split(annotations[managed], ",") {
+		if _, ok := want[k]; !ok {
+			delete(current, k)
+		}
+	}
+
+	keys := make([]string, 0, len(want))
+	for k, v := range want {
+		current[k] = v
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	if len(keys) == 0 {
+		delete(annotations, managed)
+	} else {
+		annotations[managed] = strings.Join(keys, ",")
+	}
+}
@@ -0,0 +1,129 @@
+/*
+   Copyright 2021-2022 Russell Sim <russell.sim@gmail.com>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package replicator
+
+import (
+	"reflect"
+	"testing"
+
+	utilsv1alpha1 "github.com/russell/resource-replication-operator/api/v1alpha1"
+	"github.com/russell/resource-replication-operator/replicator/common"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestDesiredLabels(t *testing.T) {
+	source := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Labels: map[string]string{
+		"ingress.example.com/tls": "true",
+		"team":                    "payments",
+	}}}
+
+	tests := []struct {
+		name   string
+		rep    Replication
+		labels map[string]string
+	}{
+		{
+			name:   "none by default",
+			rep:    Replication{},
+			labels: map[string]string{},
+		},
+		{
+			name:   "all",
+			rep:    Replication{SourceMetadata: utilsv1alpha1.SourceMetadata{Policy: utilsv1alpha1.SourceMetadataAll}},
+			labels: map[string]string{"ingress.example.com/tls": "true", "team": "payments"},
+		},
+		{
+			name: "allow list",
+			rep: Replication{SourceMetadata: utilsv1alpha1.SourceMetadata{
+				Policy:   utilsv1alpha1.SourceMetadataAllowList,
+				Prefixes: []string{"ingress.example.com/"},
+			}},
+			labels: map[string]string{"ingress.example.com/tls": "true"},
+		},
+		{
+			name: "configured labels take precedence",
+			rep: Replication{
+				SourceMetadata: utilsv1alpha1.SourceMetadata{Policy: utilsv1alpha1.SourceMetadataAll},
+				Labels:         map[string]string{"team": "platform"},
+			},
+			labels: map[string]string{"ingress.example.com/tls": "true", "team": "platform"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := desiredLabels(&tt.rep, source); !reflect.DeepEqual(got, tt.labels) {
+				t.Errorf("desiredLabels() = %v, want %v", got, tt.labels)
+			}
+		})
+	}
+}
+
+func TestDesiredAnnotationsSkipsControllerAnnotations(t *testing.T) {
+	source := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+		common.ReplicatedFromVersionAnnotation: "1",
+		corev1.LastAppliedConfigAnnotation:     "{}",
+		"description":                          "test",
+	}}}
+	rep := &Replication{SourceMetadata: utilsv1alpha1.SourceMetadata{Policy: utilsv1alpha1.SourceMetadataAll}}
+
+	want := map[string]string{"description": "test"}
+	if got := desiredAnnotations(rep, source); !reflect.DeepEqual(got, want) {
+		t.Errorf("desiredAnnotations() = %v, want %v", got, want)
+	}
+}
+
+func TestDesiredAnnotationsSkipsPushAnnotations(t *testing.T) {
+	source := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+		common.KubedSyncAnnotation:                   "",
+		common.ReflectionAllowedAnnotation:           "true",
+		common.ReflectionAllowedNamespacesAnnotation: "team-.*",
+		common.ReflectionAutoEnabledAnnotation:       "true",
+		common.ReflectionAutoNamespacesAnnotation:    "team-a",
+		"kubed.appscode.com/origin":                  "other",
+	}}}
+	rep := &Replication{SourceMetadata: utilsv1alpha1.SourceMetadata{Policy: utilsv1alpha1.SourceMetadataAll}}
+
+	want := map[string]string{"kubed.appscode.com/origin": "other"}
+	if got := desiredAnnotations(rep, source); !reflect.DeepEqual(got, want) {
+		t.Errorf("desiredAnnotations() = %v, want %v", got, want)
+	}
+}
+
+func TestSyncMetadataRemovesUnwantedKeys(t *testing.T) {
+	dest := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+		Labels: map[string]string{"copied": "true", "manual": "true"},
+		Annotations: map[string]string{
+			common.ManagedLabelsAnnotation: "copied",
+		},
+	}}
+	desired := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+		Labels: map[string]string{"configured": "true"},
+		Annotations: map[string]string{
+			common.ReplicatedFromVersionAnnotation: "2",
+		},
+	}}
+
+	syncMetadata(dest, desired)
+
+	if want := map[string]string{"configured": "true", "manual": "true"}; !reflect.DeepEqual(dest.Labels, want) {
+		t.Errorf("labels = %v, want %v", dest.Labels, want)
+	}
+	if want := map[string]string{common.ManagedLabelsAnnotation: "configured"}; !reflect.DeepEqual(dest.Annotations, want) {
+		t.Errorf("annotations = %v, want %v", dest.Annotations, want)
+	}
+}
@@ -0,0 +1,79 @@
+/*
+   Copyright 2021-2022 Russell Sim <russell.sim@gmail.com>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package replicator
+
+import (
+	"sort"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Factory builds a Replicator that logs to log.
+type Factory func(log logr.Logger) Replicator
+
+var factories = map[schema.GroupVersionKind]Factory{}
+
+func init() {
+	Register(corev1.SchemeGroupVersion.WithKind("Secret"), func(log logr.Logger) Replicator {
+		return &SecretReplicator{Log: log}
+	})
+	Register(corev1.SchemeGroupVersion.WithKind("ConfigMap"), func(log logr.Logger) Replicator {
+		return &ConfigMapReplicator{Log: log}
+	})
+}
+
+// Register adds a replicator for gvk to the registries built by
+// NewRegistry. It is intended to be called from init functions, which
+// allows additional kinds to be compiled in with a build tag.
+func Register(gvk schema.GroupVersionKind, factory Factory) {
+	factories[gvk] = factory
+}
+
+// Registry maps the kinds that have a dedicated replicator to their
+// Replicator.
+type Registry struct {
+	replicators map[schema.GroupVersionKind]Replicator
+}
+
+// NewRegistry returns a Registry containing every registered replicator.
+func NewRegistry(log logr.Logger) *Registry {
+	registry := &Registry{replicators: make(map[schema.GroupVersionKind]Replicator)}
+	for gvk, factory := range factories {
+		registry.replicators[gvk] = factory(log.WithValues("type", gvk.Kind))
+	}
+	return registry
+}
+
+// Get returns the replicator for gvk, if one is registered.
+func (r *Registry) Get(gvk schema.GroupVersionKind) (Replicator, bool) {
+	replicator, ok := r.replicators[gvk]
+	return replicator, ok
+}
+
+// Kinds returns the registered kinds in a stable order.
+func (r *Registry) Kinds() []schema.GroupVersionKind {
+	kinds := make([]schema.GroupVersionKind, 0, len(r.replicators))
+	for gvk := range r.replicators {
+		kinds = append(kinds, gvk)
+	}
+	sort.Slice(kinds, func(i, j int) bool {
+		return kinds[i].String() < kinds[j].String()
+	})
+	return kinds
+}
@@ -0,0 +1,47 @@
+/*
+   Copyright 2021-2022 Russell Sim <russell.sim@gmail.com>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package replicator
+
+import (
+	"context"
+	"github.com/russell/resource-replication-operator/replicator/common"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"strings"
+)
+
+// Release turns dest into an orphan that is no longer managed by owner by
+// removing the owner reference to owner and every annotation of this
+// controller. The replicated content, labels and annotations are kept.
+func Release(ctx context.Context, c client.Client, dest client.Object, owner metav1.Object) error {
+	var references []metav1.OwnerReference
+	for _, reference := range dest.GetOwnerReferences() {
+		if reference.UID != owner.GetUID() {
+			references = append(references, reference)
+		}
+	}
+	dest.SetOwnerReferences(references)
+
+	annotations := dest.GetAnnotations()
+	for k := range annotations {
+		if strings.HasPrefix(k, common.AnnotationPrefix) {
+			delete(annotations, k)
+		}
+	}
+	dest.SetAnnotations(annotations)
+	return c.Update(ctx, dest)
+}
@@ -0,0 +1,71 @@
+/*
+   Copyright 2021-2022 Russell Sim <russell.sim@gmail.com>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package replicator
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/russell/resource-replication-operator/replicator/common"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestRelease(t *testing.T) {
+	controller := true
+	owner := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "owner", Namespace: "apps", UID: "owner"}}
+	other := metav1.OwnerReference{APIVersion: "v1", Kind: "ConfigMap", Name: "other", UID: "other"}
+	dest := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "copy",
+			Namespace: "apps",
+			OwnerReferences: []metav1.OwnerReference{
+				{APIVersion: "v1", Kind: "ConfigMap", Name: "owner", UID: "owner", Controller: &controller},
+				other,
+			},
+			Labels: map[string]string{"team": "payments"},
+			Annotations: map[string]string{
+				common.ReplicatedHashAnnotation: "hash",
+				common.ManagedLabelsAnnotation:  "team",
+				"example.com/kept":              "true",
+			},
+		},
+		Data: map[string][]byte{"key": []byte("value")},
+	}
+	c := fake.NewClientBuilder().WithObjects(dest).Build()
+
+	if err := Release(context.Background(), c, dest, owner); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+
+	released := &corev1.Secret{}
+	if err := c.Get(context.Background(), client.ObjectKeyFromObject(dest), released); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if !reflect.DeepEqual(released.OwnerReferences, []metav1.OwnerReference{other}) {
+		t.Errorf("Release() ownerReferences = %v, want only %v", released.OwnerReferences, other)
+	}
+	if !reflect.DeepEqual(released.Annotations, map[string]string{"example.com/kept": "true"}) {
+		t.Errorf("Release() annotations = %v, want only the foreign annotation", released.Annotations)
+	}
+	if released.Labels["team"] != "payments" || string(released.Data["key"]) != "value" {
+		t.Errorf("Release() changed the replicated content: %v %v", released.Labels, released.Data)
+	}
+}
@@ -0,0 +1,319 @@
+/*
+   Copyright 2021-2022 Russell Sim <russell.sim@gmail.com>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package replicator
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"github.com/go-logr/logr"
+	utilsv1alpha1 "github.com/russell/resource-replication-operator/api/v1alpha1"
+	"github.com/russell/resource-replication-operator/replicator/common"
+	kerrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"strings"
+	"time"
+)
+
+// Replication describes the copy of a single source object to a single
+// destination object.
+type Replication struct {
+	Source      types.NamespacedName
+	Destination types.NamespacedName
+	// OwnerReferences are set on the destination object.
+	OwnerReferences []metav1.OwnerReference
+	// RemoteOwner replaces OwnerReferences for destinations in remote
+	// clusters or other namespaces than their owner, see RemoteOwner.
+	RemoteOwner string
+	// SourceConsented skips checking that the source allows replication
+	// to the destination namespace, for sources that asked to be
+	// replicated there.
+	SourceConsented bool
+	// CompatibilityAnnotations also accepts the Reflector annotations of
+	// the source as consent, see common.ReflectionAllowedAnnotation.
+	CompatibilityAnnotations bool
+	// Fields lists the top-level fields copied by replicators that are
+	// not specific to a kind, every field is copied when empty.
+	Fields []string
+	// Labels and Annotations are added to the destination object.
+	Labels      map[string]string
+	Annotations map[string]string
+	// SourceMetadata selects the labels and annotations copied from the
+	// source object.
+	SourceMetadata utilsv1alpha1.SourceMetadata
+	// DriftPolicy controls what happens when the destination was modified
+	// since it was last replicated, defaults to DriftCorrect.
+	DriftPolicy utilsv1alpha1.DriftPolicy
+	// Transform modifies the data of Secrets and ConfigMaps.
+	Transform utilsv1alpha1.ReplicatedResourceTransform
+	// ConflictPolicy controls what happens when the destination exists
+	// and isn't controlled by the controller of OwnerReferences, defaults
+	// to ConflictFail.
+	ConflictPolicy utilsv1alpha1.ConflictPolicy
+}
+
+// Result describes the outcome of replicating a destination object.
+type Result struct {
+	Operation controllerutil.OperationResult
+	// Object is the destination object.
+	Object client.Object
+	// Drifted is set when the replicated content of the destination no
+	// longer matches its ReplicatedHashAnnotation.
+	Drifted bool
+	// SourceChanged is set when the destination existed and was replicated
+	// from another version of the source.
+	SourceChanged bool
+	// Conflict is set when the destination existed, wasn't controlled by
+	// the owner and couldn't be adopted.
+	Conflict bool
+	// Adopted is set when the destination existed without a controller
+	// and was adopted.
+	Adopted bool
+	// Hash is the content hash of the replicated content.
+	Hash string
+	// Sources are the source objects that were replicated.
+	Sources []client.Object
+}
+
+// Replicator replicates objects of a single kind.
+type Replicator interface {
+	// NewObject returns an empty object of the replicated kind, the
+	// controller watches these as sources and owns them as destinations.
+	NewObject() client.Object
+	// Fetch reads the source object.
+	Fetch(ctx context.Context, c client.Client, key types.NamespacedName) (client.Object, error)
+	// Desired computes the destination object for a source object.
+	Desired(rep *Replication, source client.Object) (client.Object, error)
+	// Apply creates or updates the destination object to match desired.
+	Apply(ctx context.Context, c client.Client, rep *Replication, desired client.Object) (Result, error)
+}
+
+// Replicate fetches the source of rep and creates or updates the
+// destination using replicator.
+func Replicate(ctx context.Context, c client.Client, log logr.Logger, replicator Replicator, rep *Replication) (Result, error) {
+	source, err := replicator.Fetch(ctx, c, rep.Source)
+	if err != nil {
+		log.Info(fmt.Sprintf("Error reading source: %s", err), "source", rep.Source.String())
+		return Result{Operation: controllerutil.OperationResultNone}, err
+	}
+	return ReplicateFrom(ctx, c, log, replicator, rep, source)
+}
+
+// ReplicateFrom creates or updates the destination of rep from a source
+// that has already been fetched, which allows one source to be copied to
+// many destinations.
+func ReplicateFrom(ctx context.Context, c client.Client, log logr.Logger, replicator Replicator, rep *Replication, source client.Object) (Result, error) {
+	return replicateFrom(ctx, c, c, log, replicator, rep, source)
+}
+
+// ReplicateRemote creates or updates the destination of rep with remote, a
+// client of another cluster, from a source fetched with local. The source
+// must allow replication to the destination namespace of the local
+// cluster, the namespace of the same name in the remote cluster isn't
+// checked, so callers must check that the remote cluster accepts copies in
+// that namespace.
+func ReplicateRemote(ctx context.Context, local, remote client.Client, log logr.Logger, replicator Replicator, rep *Replication, source client.Object) (Result, error) {
+	return replicateFrom(ctx, local, remote, log, replicator, rep, source)
+}
+
+// replicateFrom authorizes the replication of source with local and
+// replicates it with c.
+func replicateFrom(ctx context.Context, local, c client.Client, log logr.Logger, replicator Replicator, rep *Replication, source client.Object) (Result, error) {
+	log = log.WithValues(
+		"source", rep.Source.String(),
+		"destination", rep.Destination.String())
+	log.Info(fmt.Sprintf("Replicating resourceVersion: %s", source.GetResourceVersion()))
+
+	if !rep.SourceConsented {
+		if err := authorize(ctx, local, source, rep.Destination.Namespace, rep.CompatibilityAnnotations); err != nil {
+			return Result{Operation: controllerutil.OperationResultNone}, err
+		}
+	}
+
+	desired, err := replicator.Desired(rep, source)
+	if err != nil {
+		return Result{Operation: controllerutil.OperationResultNone}, err
+	}
+
+	result, err := replicator.Apply(ctx, c, rep, desired)
+	result.Sources = []client.Object{source}
+	if result.Drifted {
+		log.Info("Destination was modified since it was replicated", "driftPolicy", rep.DriftPolicy)
+	}
+	log.Info(fmt.Sprintf("Updated destination %s", result.Operation))
+	return result, err
+}
+
+// fetch reads the source object into obj, kind is only used to describe
+// the source when it can't be found.
+func fetch(ctx context.Context, c client.Client, key types.NamespacedName, obj client.Object, kind string) error {
+	if err := c.Get(ctx, key, obj); err != nil {
+		if kerrors.IsNotFound(err) {
+			return fmt.Errorf("Could not find source %s %s/%s: %w", kind, key.Namespace, key.Name, err)
+		}
+		return err
+	}
+	return nil
+}
+
+// desiredMeta returns the metadata shared by every destination object,
+// content is the replicated content that is hashed to detect drift.
+func desiredMeta(rep *Replication, source client.Object, content ...interface{}) metav1.ObjectMeta {
+	annotations := desiredAnnotations(rep, source)
+	annotations[common.ReplicatedAtAnnotation] = time.Now().Format(time.RFC3339)
+	annotations[common.ReplicatedFromVersionAnnotation] = source.GetResourceVersion()
+	annotations[common.ReplicatedHashAnnotation] = contentHash(content...)
+	if rep.RemoteOwner != "" {
+		annotations[common.RemoteOwnerAnnotation] = rep.RemoteOwner
+	}
+	return metav1.ObjectMeta{
+		Name:            rep.Destination.Name,
+		Namespace:       rep.Destination.Namespace,
+		OwnerReferences: rep.OwnerReferences,
+		Labels:          desiredLabels(rep, source),
+		Annotations:     annotations,
+	}
+}
+
+// contentHash returns the SHA-256 of the JSON encoding of content.
+func contentHash(content ...interface{}) string {
+	data, err := json.Marshal(content)
+	if err != nil {
+		// The replicated content always comes from a decoded object
+		panic(err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// createOrUpdate creates or updates dest, which must have the name and
+// namespace of desired set, with the metadata of desired. update copies the
+// replicated content, it is called when the hash of the desired content
+// differs from the ReplicatedHashAnnotation of dest, or when inSync reports
+// that dest no longer has the content it was last replicated with and
+// rep.DriftPolicy allows the drift to be corrected.
+func createOrUpdate(ctx context.Context, c client.Client, rep *Replication, desired, dest client.Object, inSync func() bool, update func()) (Result, error) {
+	result := Result{Object: dest, Hash: desired.GetAnnotations()[common.ReplicatedHashAnnotation]}
+	op, err := controllerutil.CreateOrUpdate(ctx, c, dest, func() error {
+		if dest.GetResourceVersion() != "" {
+			if err := checkConflict(rep, dest, desired, &result); err != nil {
+				return err
+			}
+		}
+		dest.SetOwnerReferences(desired.GetOwnerReferences())
+		syncMetadata(dest, desired)
+
+		annotations := dest.GetAnnotations()
+		version := desired.GetAnnotations()[common.ReplicatedFromVersionAnnotation]
+		if previous := annotations[common.ReplicatedFromVersionAnnotation]; previous != "" && previous != version {
+			result.SourceChanged = true
+		}
+		// Only check the content for drift if the desired content hasn't
+		// changed, inSync then compares dest with the content its hash
+		// annotation was computed from.
+		if annotations[common.ReplicatedHashAnnotation] == result.Hash {
+			if rep.DriftPolicy == utilsv1alpha1.DriftIgnore || inSync() {
+				return nil
+			}
+			result.Drifted = true
+			if rep.DriftPolicy == utilsv1alpha1.DriftReportOnly {
+				return nil
+			}
+		}
+
+		for _, k := range replicationAnnotations {
+			annotations[k] = desired.GetAnnotations()[k]
+		}
+		dest.SetAnnotations(annotations)
+		update()
+		return nil
+	})
+	result.Operation = op
+	return result, err
+}
+
+// RemoteOwner returns the RemoteOwnerAnnotation of the destinations of
+// owner, of kind kind, that can't have an owner reference to it.
+func RemoteOwner(kind string, owner client.Object) string {
+	return fmt.Sprintf("%s/%s/%s", kind, owner.GetNamespace(), owner.GetName())
+}
+
+// manager identifies the object managing a destination.
+type manager struct {
+	id   string
+	kind string
+	name string
+}
+
+// managerOf returns the manager of obj, its controller or its
+// RemoteOwnerAnnotation, or nil if it has none.
+func managerOf(obj client.Object) *manager {
+	if controller := metav1.GetControllerOfNoCopy(obj); controller != nil {
+		return &manager{id: string(controller.UID), kind: controller.Kind, name: controller.Name}
+	}
+	if owner := obj.GetAnnotations()[common.RemoteOwnerAnnotation]; owner != "" {
+		parts := strings.SplitN(owner, "/", 3)
+		return &manager{id: owner, kind: parts[0], name: parts[len(parts)-1]}
+	}
+	return nil
+}
+
+// checkConflict returns a DestinationConflict error unless dest, which
+// already exists, is managed by the manager of desired or can be adopted
+// according to rep.ConflictPolicy. Adopted destinations are marked with the
+// AdoptedAnnotation.
+func checkConflict(rep *Replication, dest, desired client.Object, result *Result) error {
+	controller := managerOf(desired)
+	if controller == nil {
+		return nil
+	}
+	owner := managerOf(dest)
+	if owner != nil && owner.id == controller.id {
+		return nil
+	}
+
+	adopt := false
+	if owner == nil {
+		switch rep.ConflictPolicy {
+		case utilsv1alpha1.ConflictAdopt:
+			adopt = true
+		case utilsv1alpha1.ConflictAdoptIfMatchingLabel:
+			adopt = dest.GetLabels()[common.AdoptLabel] == controller.name
+		}
+	}
+	if !adopt {
+		result.Conflict = true
+		if owner != nil {
+			return newError("DestinationConflict", "Destination %s is controlled by %s %s", rep.Destination, owner.kind, owner.name)
+		}
+		return newError("DestinationConflict", "Destination %s already exists and isn't managed by %s %s", rep.Destination, controller.kind, controller.name)
+	}
+
+	result.Adopted = true
+	annotations := dest.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[common.AdoptedAnnotation] = time.Now().Format(time.RFC3339)
+	dest.SetAnnotations(annotations)
+	return nil
+}
@@ -0,0 +1,232 @@
+/*
+   Copyright 2021-2022 Russell Sim <russell.sim@gmail.com>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package replicator
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	utilsv1alpha1 "github.com/russell/resource-replication-operator/api/v1alpha1"
+	"github.com/russell/resource-replication-operator/replicator/common"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+func TestReplicateFromResult(t *testing.T) {
+	controller := true
+	owner := metav1.OwnerReference{APIVersion: "utils.simopolis.xyz/v1alpha1", Kind: "ReplicatedResource", Name: "copy", UID: "owner", Controller: &controller}
+	other := metav1.OwnerReference{APIVersion: "utils.simopolis.xyz/v1alpha1", Kind: "ReplicatedResource", Name: "other", UID: "other", Controller: &controller}
+
+	tests := []struct {
+		name          string
+		existing      []client.Object
+		policy        utilsv1alpha1.ConflictPolicy
+		operation     controllerutil.OperationResult
+		sourceChanged bool
+		conflict      bool
+		adopted       bool
+		reason        string
+	}{
+		{
+			name:      "created",
+			operation: controllerutil.OperationResultCreated,
+		},
+		{
+			name: "source changed",
+			existing: []client.Object{&corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+				Name: "copy", Namespace: "apps",
+				OwnerReferences: []metav1.OwnerReference{owner},
+				Annotations:     map[string]string{common.ReplicatedFromVersionAnnotation: "1"},
+			}}},
+			operation:     controllerutil.OperationResultUpdated,
+			sourceChanged: true,
+		},
+		{
+			name: "controlled by another owner",
+			existing: []client.Object{&corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+				Name: "copy", Namespace: "apps",
+				OwnerReferences: []metav1.OwnerReference{other},
+			}}},
+			policy:    utilsv1alpha1.ConflictAdopt,
+			operation: controllerutil.OperationResultNone,
+			conflict:  true,
+			reason:    "DestinationConflict",
+		},
+		{
+			name: "not managed",
+			existing: []client.Object{&corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+				Name: "copy", Namespace: "apps",
+			}}},
+			operation: controllerutil.OperationResultNone,
+			conflict:  true,
+			reason:    "DestinationConflict",
+		},
+		{
+			name: "adopted",
+			existing: []client.Object{&corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+				Name: "copy", Namespace: "apps",
+			}}},
+			policy:    utilsv1alpha1.ConflictAdopt,
+			operation: controllerutil.OperationResultUpdated,
+			adopted:   true,
+		},
+		{
+			name: "adopted with a matching label",
+			existing: []client.Object{&corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+				Name: "copy", Namespace: "apps",
+				Labels: map[string]string{common.AdoptLabel: "copy"},
+			}}},
+			policy:    utilsv1alpha1.ConflictAdoptIfMatchingLabel,
+			operation: controllerutil.OperationResultUpdated,
+			adopted:   true,
+		},
+		{
+			name: "label of another owner",
+			existing: []client.Object{&corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+				Name: "copy", Namespace: "apps",
+				Labels: map[string]string{common.AdoptLabel: "other"},
+			}}},
+			policy:    utilsv1alpha1.ConflictAdoptIfMatchingLabel,
+			operation: controllerutil.OperationResultNone,
+			conflict:  true,
+			reason:    "DestinationConflict",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			source := &corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "source", Namespace: "apps", ResourceVersion: "2"},
+				Data:       map[string][]byte{"key": []byte("value")},
+			}
+			c := fake.NewClientBuilder().WithObjects(tt.existing...).Build()
+			rep := &Replication{
+				Source:          types.NamespacedName{Namespace: "apps", Name: "source"},
+				Destination:     types.NamespacedName{Namespace: "apps", Name: "copy"},
+				OwnerReferences: []metav1.OwnerReference{owner},
+				ConflictPolicy:  tt.policy,
+			}
+
+			result, err := ReplicateFrom(context.Background(), c, logr.Discard(), &SecretReplicator{}, rep, source)
+			if tt.reason != "" {
+				if ReasonFor(err) != tt.reason {
+					t.Errorf("ReplicateFrom() error = %v, want reason %s", err, tt.reason)
+				}
+			} else if err != nil {
+				t.Fatalf("ReplicateFrom() error = %v", err)
+			}
+			if result.Operation != tt.operation {
+				t.Errorf("ReplicateFrom() operation = %s, want %s", result.Operation, tt.operation)
+			}
+			if result.SourceChanged != tt.sourceChanged {
+				t.Errorf("ReplicateFrom() sourceChanged = %v, want %v", result.SourceChanged, tt.sourceChanged)
+			}
+			if result.Conflict != tt.conflict {
+				t.Errorf("ReplicateFrom() conflict = %v, want %v", result.Conflict, tt.conflict)
+			}
+			if result.Adopted != tt.adopted {
+				t.Errorf("ReplicateFrom() adopted = %v, want %v", result.Adopted, tt.adopted)
+			}
+
+			dest := &corev1.Secret{}
+			if err := c.Get(context.Background(), rep.Destination, dest); err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if _, ok := dest.Annotations[common.AdoptedAnnotation]; ok != tt.adopted {
+				t.Errorf("adopted annotation = %v, want %v", ok, tt.adopted)
+			}
+			if controlled := metav1.IsControlledBy(dest, &corev1.Secret{ObjectMeta: metav1.ObjectMeta{UID: owner.UID}}); controlled == tt.conflict {
+				t.Errorf("destination controlled by owner = %v, want %v", controlled, !tt.conflict)
+			}
+			if len(result.Sources) != 1 || result.Sources[0] != source {
+				t.Errorf("ReplicateFrom() sources = %v, want the source", result.Sources)
+			}
+		})
+	}
+}
+
+func TestReplicateRemote(t *testing.T) {
+	source := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "source", Namespace: "apps", ResourceVersion: "2"},
+		Data:       map[string][]byte{"key": []byte("value")},
+	}
+	local := fake.NewClientBuilder().Build()
+	remote := fake.NewClientBuilder().WithObjects(&corev1.Secret{ObjectMeta: metav1.ObjectMeta{
+		Name: "other", Namespace: "apps",
+		Annotations: map[string]string{common.RemoteOwnerAnnotation: "ReplicatedResource/apps/other"},
+	}}).Build()
+
+	rep := &Replication{
+		Source:      types.NamespacedName{Namespace: "apps", Name: "source"},
+		Destination: types.NamespacedName{Namespace: "apps", Name: "copy"},
+		RemoteOwner: "ReplicatedResource/apps/copy",
+	}
+	result, err := ReplicateRemote(context.Background(), local, remote, logr.Discard(), &SecretReplicator{}, rep, source)
+	if err != nil {
+		t.Fatalf("ReplicateRemote() error = %v", err)
+	}
+	if result.Operation != controllerutil.OperationResultCreated {
+		t.Errorf("ReplicateRemote() operation = %s, want %s", result.Operation, controllerutil.OperationResultCreated)
+	}
+	dest := &corev1.Secret{}
+	if err := remote.Get(context.Background(), rep.Destination, dest); err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if owner := dest.Annotations[common.RemoteOwnerAnnotation]; owner != rep.RemoteOwner {
+		t.Errorf("remote owner annotation = %q, want %q", owner, rep.RemoteOwner)
+	}
+	if err := local.Get(context.Background(), rep.Destination, &corev1.Secret{}); err == nil {
+		t.Errorf("destination was created with the local client")
+	}
+
+	// Another owner's copy in the remote cluster is a conflict
+	rep.Destination.Name = "other"
+	result, err = ReplicateRemote(context.Background(), local, remote, logr.Discard(), &SecretReplicator{}, rep, source)
+	if ReasonFor(err) != "DestinationConflict" || !result.Conflict {
+		t.Errorf("ReplicateRemote() error = %v, conflict = %v, want a DestinationConflict", err, result.Conflict)
+	}
+}
+
+func TestReplicateFromSourceConsented(t *testing.T) {
+	source := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "source", Namespace: "apps", ResourceVersion: "2"},
+		Data:       map[string][]byte{"key": []byte("value")},
+	}
+	c := fake.NewClientBuilder().WithObjects(&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "web"}}).Build()
+	rep := &Replication{
+		Source:      types.NamespacedName{Namespace: "apps", Name: "source"},
+		Destination: types.NamespacedName{Namespace: "web", Name: "source"},
+		RemoteOwner: "Secret/apps/source",
+	}
+
+	if _, err := ReplicateFrom(context.Background(), c, logr.Discard(), &SecretReplicator{}, rep, source); ReasonFor(err) != "Forbidden" {
+		t.Fatalf("ReplicateFrom() error = %v, want Forbidden", err)
+	}
+
+	rep.SourceConsented = true
+	result, err := ReplicateFrom(context.Background(), c, logr.Discard(), &SecretReplicator{}, rep, source)
+	if err != nil {
+		t.Fatalf("ReplicateFrom() error = %v", err)
+	}
+	if result.Operation != controllerutil.OperationResultCreated {
+		t.Errorf("ReplicateFrom() operation = %s, want %s", result.Operation, controllerutil.OperationResultCreated)
+	}
+}
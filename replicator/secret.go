@@ -18,85 +18,69 @@ package replicator
 
 import (
 	"context"
-	"errors"
-	"fmt"
 	"github.com/go-logr/logr"
-	utilsv1alpha1 "github.com/russell/resource-replication-operator/api/v1alpha1"
-	"github.com/russell/resource-replication-operator/replicator/common"
 	corev1 "k8s.io/api/core/v1"
-	kerrors "k8s.io/apimachinery/pkg/api/errors"
-	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/api/equality"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
-	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
-	"time"
 )
 
+// SecretReplicator replicates the type and data of Secrets.
 type SecretReplicator struct {
-	client.Client
-	Log    logr.Logger
-	Scheme *runtime.Scheme
+	Log logr.Logger
 }
 
-func (r *SecretReplicator) ReplicateSecret(ctx context.Context, rep *utilsv1alpha1.ReplicatedResource) (controllerutil.OperationResult, *corev1.Secret, error) {
-	sourceNamespacedName := types.NamespacedName{Namespace: rep.Spec.Source.Namespace, Name: rep.Spec.Source.Name}
-	destNamespacedName := types.NamespacedName{Namespace: rep.Namespace, Name: rep.Name}
+func (r *SecretReplicator) NewObject() client.Object {
+	return &corev1.Secret{}
+}
 
-	log := r.Log.WithValues(
-		"type", "secret",
-		"source", fmt.Sprintf("%s/%s", sourceNamespacedName.Namespace, sourceNamespacedName.Name),
-		"destination", fmt.Sprintf("%s/%s", destNamespacedName.Namespace, destNamespacedName.Name))
+func (r *SecretReplicator) Fetch(ctx context.Context, c client.Client, key types.NamespacedName) (client.Object, error) {
 	source := &corev1.Secret{}
-	if err := r.Get(ctx, sourceNamespacedName, source); err != nil {
-		if !kerrors.IsNotFound(err) {
-			log.Info("Error reading source")
-			return controllerutil.OperationResultNone, nil, err
-		} else {
-			log.Info("Could not find source Secret")
-			return controllerutil.OperationResultNone, nil, errors.New(fmt.Sprintf("Could not find source secret %s/%s",
-				rep.Spec.Source.Namespace, rep.Spec.Source.Name))
-		}
+	if err := fetch(ctx, c, key, source, "secret"); err != nil {
+		return nil, err
 	}
-	log.Info(fmt.Sprintf("Replicating Secret resourceVersion: %s", source.ResourceVersion))
+	return source, nil
+}
 
-	t := true
-	owners := []metav1.OwnerReference{
-		{
-			Name:               rep.Name,
-			Kind:               rep.Kind,
-			APIVersion:         rep.APIVersion,
-			UID:                rep.UID,
-			Controller:         &t,
-			BlockOwnerDeletion: &t,
-		},
+func (r *SecretReplicator) Desired(rep *Replication, source client.Object) (client.Object, error) {
+	secret := source.(*corev1.Secret)
+	mapping, err := keyMapping(mapKeys(secret.Data), rep.Transform.Keys)
+	if err != nil {
+		return nil, err
 	}
-
-	dest := &corev1.Secret{
-		ObjectMeta: metav1.ObjectMeta{
-			Name:            rep.ObjectMeta.Name,
-			Namespace:       rep.ObjectMeta.Namespace,
-			OwnerReferences: owners,
-		},
+	data := transformKeys(secret.Data, mapping)
+	sourceData := make(map[string]string, len(secret.Data))
+	for key, value := range secret.Data {
+		sourceData[key] = string(value)
 	}
-
-	op, err := controllerutil.CreateOrUpdate(ctx, r.Client, dest, func() error {
-		// Only update if there is a new version
-		if dest.Annotations != nil && dest.Annotations[common.ReplicatedFromVersionAnnotation] == source.ResourceVersion {
-			return nil
+	rendered, err := renderTemplates(rep.Transform.Template, secret, sourceData)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range rendered {
+		if data == nil {
+			data = map[string][]byte{}
 		}
+		data[key] = []byte(value)
+	}
+	return &corev1.Secret{
+		ObjectMeta: desiredMeta(rep, source, secret.Type, data),
+		Type:       secret.Type,
+		Data:       data,
+	}, nil
+}
 
-		if dest.Annotations == nil {
-			dest.Annotations = make(map[string]string)
-		}
-		dest.Annotations[common.ReplicatedAtAnnotation] = time.Now().Format(time.RFC3339)
-		dest.Annotations[common.ReplicatedFromVersionAnnotation] = source.ResourceVersion
-		log.Info(fmt.Sprintf("Updating secret %s", source.ResourceVersion))
-		dest.Type = source.Type
-		dest.Data = source.Data
-		return nil
-	})
-	log.Info(fmt.Sprintf("Updated Secret %s", op))
+func (r *SecretReplicator) Apply(ctx context.Context, c client.Client, rep *Replication, desired client.Object) (Result, error) {
+	want := desired.(*corev1.Secret)
+	dest := &corev1.Secret{}
+	dest.Name = want.Name
+	dest.Namespace = want.Namespace
 
-	return op, dest, err
+	return createOrUpdate(ctx, c, rep, want, dest, func() bool {
+		return dest.Type == want.Type && equality.Semantic.DeepEqual(dest.Data, want.Data)
+	}, func() {
+		r.Log.Info("Updating secret", "destination", client.ObjectKeyFromObject(dest).String())
+		dest.Type = want.Type
+		dest.Data = want.Data
+	})
 }
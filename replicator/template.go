@@ -0,0 +1,138 @@
+/*
+   Copyright 2021-2022 Russell Sim <russell.sim@gmail.com>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package replicator
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sort"
+	"strings"
+	"text/template"
+)
+
+// templateData is the value templates are evaluated against.
+type templateData struct {
+	// Data holds the data of the source object as strings.
+	Data map[string]string
+	// Metadata is the metadata of the source object.
+	Metadata templateMetadata
+}
+
+type templateMetadata struct {
+	Name        string
+	Namespace   string
+	Labels      map[string]string
+	Annotations map[string]string
+}
+
+// renderTemplates evaluates every template against the data and metadata
+// of source and returns the rendered values by destination key. Templates
+// are named after their key so errors report the key and line.
+func renderTemplates(templates map[string]string, source metav1.Object, data map[string]string) (map[string]string, error) {
+	if len(templates) == 0 {
+		return nil, nil
+	}
+	input := templateData{
+		Data: data,
+		Metadata: templateMetadata{
+			Name:        source.GetName(),
+			Namespace:   source.GetNamespace(),
+			Labels:      source.GetLabels(),
+			Annotations: source.GetAnnotations(),
+		},
+	}
+
+	keys := make([]string, 0, len(templates))
+	for key := range templates {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	rendered := make(map[string]string, len(templates))
+	for _, key := range keys {
+		tmpl, err := template.New(key).Option("missingkey=error").Funcs(templateFuncs).Parse(templates[key])
+		if err != nil {
+			return nil, newError("TemplateError", "%s", err)
+		}
+		var out bytes.Buffer
+		if err := tmpl.Execute(&out, input); err != nil {
+			return nil, newError("TemplateError", "%s", err)
+		}
+		rendered[key] = out.String()
+	}
+	return rendered, nil
+}
+
+// templateFuncs is a subset of the sprig functions that can't reach
+// outside of the template data, with the same argument order as sprig.
+var templateFuncs = template.FuncMap{
+	"b64enc": func(s string) string {
+		return base64.StdEncoding.EncodeToString([]byte(s))
+	},
+	"b64dec": func(s string) (string, error) {
+		decoded, err := base64.StdEncoding.DecodeString(s)
+		return string(decoded), err
+	},
+	"sha256sum": func(s string) string {
+		sum := sha256.Sum256([]byte(s))
+		return hex.EncodeToString(sum[:])
+	},
+	"trim":       strings.TrimSpace,
+	"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+	"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+	"upper":      strings.ToUpper,
+	"lower":      strings.ToLower,
+	"replace":    func(old, new, s string) string { return strings.ReplaceAll(s, old, new) },
+	"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+	"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+	"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+	"splitList":  func(sep, s string) []string { return strings.Split(s, sep) },
+	"join": func(sep string, elems []string) string {
+		return strings.Join(elems, sep)
+	},
+	"quote":  func(s string) string { return fmt.Sprintf("%q", s) },
+	"squote": func(s string) string { return "'" + s + "'" },
+	"indent": indent,
+	"nindent": func(spaces int, s string) string {
+		return "\n" + indent(spaces, s)
+	},
+	"default": func(def, value interface{}) interface{} {
+		if value == nil || value == "" {
+			return def
+		}
+		return value
+	},
+	"toJson": func(v interface{}) (string, error) {
+		out, err := json.Marshal(v)
+		return string(out), err
+	},
+	"toPrettyJson": func(v interface{}) (string, error) {
+		out, err := json.MarshalIndent(v, "", "  ")
+		return string(out), err
+	},
+}
+
+// indent prefixes every line of s with spaces.
+func indent(spaces int, s string) string {
+	pad := strings.Repeat(" ", spaces)
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
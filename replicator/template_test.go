@@ -0,0 +1,104 @@
+/*
+   Copyright 2021-2022 Russell Sim <russell.sim@gmail.com>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package replicator
+
+import (
+	"strings"
+	"testing"
+
+	utilsv1alpha1 "github.com/russell/resource-replication-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRenderTemplates(t *testing.T) {
+	source := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "databases"}}
+	data := map[string]string{"host": "db.example.com", "user": "app", "password": " secret\n"}
+
+	tests := []struct {
+		name     string
+		template string
+		want     string
+		err      string
+	}{
+		{
+			name:     "jdbc.url",
+			template: `jdbc:postgresql://{{ .Data.host }}/{{ .Metadata.Name }}?user={{ .Data.user }}&password={{ trim .Data.password }}`,
+			want:     "jdbc:postgresql://db.example.com/db?user=app&password=secret",
+		},
+		{
+			name:     "config.yaml",
+			template: "database:{{ printf \"host: %s\\nuser: %s\" .Data.host .Data.user | nindent 2 }}",
+			want:     "database:\n  host: db.example.com\n  user: app",
+		},
+		{
+			name:     "unknown",
+			template: "{{ .Data.user }}\n{{ env \"HOME\" }}",
+			err:      `template: unknown:2: function "env" not defined`,
+		},
+		{
+			name:     "credentials",
+			template: `{{ .Data.user | b64enc }}:{{ toJson .Metadata.Namespace }}`,
+			want:     `YXBw:"databases"`,
+		},
+		{
+			name:     "missing",
+			template: "{{ .Data.host }}\n{{ .Data.port }}",
+			err:      `template: missing:2:8: executing "missing" at <.Data.port>: map has no entry for key "port"`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rendered, err := renderTemplates(map[string]string{tt.name: tt.template}, source, data)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err || ReasonFor(err) != "TemplateError" {
+					t.Fatalf("renderTemplates() error = %v, want %s", err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("renderTemplates() error = %v", err)
+			}
+			if got := rendered[tt.name]; got != tt.want {
+				t.Errorf("renderTemplates() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSecretDesiredRendersTemplates(t *testing.T) {
+	source := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "source", Namespace: "default"},
+		Data:       map[string][]byte{"user": []byte("admin"), "password": []byte("hunter2")},
+	}
+	rep := &Replication{Transform: utilsv1alpha1.ReplicatedResourceTransform{
+		Keys:     &utilsv1alpha1.KeyTransform{Include: []string{"user"}},
+		Template: map[string]string{"config.yaml": "user: {{ .Data.user }}\npassword: {{ .Data.password }}"},
+	}}
+
+	desired, err := (&SecretReplicator{}).Desired(rep, source)
+	if err != nil {
+		t.Fatalf("Desired() error = %v", err)
+	}
+	data := desired.(*corev1.Secret).Data
+	if got := string(data["config.yaml"]); !strings.Contains(got, "password: hunter2") {
+		t.Errorf("config.yaml = %q, want the password", got)
+	}
+	if _, ok := data["password"]; ok {
+		t.Errorf("password was replicated although it is not included")
+	}
+}
@@ -0,0 +1,115 @@
+/*
+   Copyright 2021-2022 Russell Sim <russell.sim@gmail.com>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package replicator
+
+import (
+	utilsv1alpha1 "github.com/russell/resource-replication-operator/api/v1alpha1"
+	"path"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// keyMapping returns the destination key for every source key that is
+// replicated according to keys. Keys that are named explicitly must be in
+// sourceKeys.
+func keyMapping(sourceKeys []string, keys *utilsv1alpha1.KeyTransform) (map[string]string, error) {
+	mapping := make(map[string]string, len(sourceKeys))
+	if keys == nil {
+		for _, key := range sourceKeys {
+			mapping[key] = key
+		}
+		return mapping, nil
+	}
+
+	for _, pattern := range append(slices.Clone(keys.Include), keys.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, newError("InvalidTransform", "Invalid key pattern %q: %s", pattern, err)
+		}
+	}
+
+	var required []string
+	for _, pattern := range keys.Include {
+		if !strings.ContainsAny(pattern, `*?[\`) {
+			required = append(required, pattern)
+		}
+	}
+	for key := range keys.Rename {
+		required = append(required, key)
+	}
+	sort.Strings(required)
+	for _, key := range required {
+		if !slices.Contains(sourceKeys, key) {
+			return nil, newError("MissingKey", "Key %q is missing from the source", key)
+		}
+	}
+
+	sources := make(map[string]string, len(sourceKeys))
+	for _, key := range sourceKeys {
+		renamed, isRenamed := keys.Rename[key]
+		if !isRenamed && len(keys.Include) > 0 && !matchesAny(keys.Include, key) {
+			continue
+		}
+		if matchesAny(keys.Exclude, key) {
+			continue
+		}
+		dest := key
+		if isRenamed {
+			dest = renamed
+		}
+		if other, ok := sources[dest]; ok {
+			return nil, newError("InvalidTransform", "Keys %q and %q are both replicated as %q", other, key, dest)
+		}
+		sources[dest] = key
+		mapping[key] = dest
+	}
+	return mapping, nil
+}
+
+// transformKeys returns data with its keys replaced according to mapping,
+// keys without a mapping are dropped.
+func transformKeys[V any](data map[string]V, mapping map[string]string) map[string]V {
+	if data == nil {
+		return nil
+	}
+	transformed := make(map[string]V, len(data))
+	for key, value := range data {
+		if dest, ok := mapping[key]; ok {
+			transformed[dest] = value
+		}
+	}
+	return transformed
+}
+
+func matchesAny(patterns []string, key string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, key); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// mapKeys returns the sorted keys of data.
+func mapKeys[V any](data map[string]V) []string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
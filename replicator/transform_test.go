@@ -0,0 +1,163 @@
+/*
+   Copyright 2021-2022 Russell Sim <russell.sim@gmail.com>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package replicator
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	utilsv1alpha1 "github.com/russell/resource-replication-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+func TestKeyMapping(t *testing.T) {
+	sourceKeys := []string{"ca.crt", "tls.crt", "tls.key"}
+
+	tests := []struct {
+		name    string
+		keys    *utilsv1alpha1.KeyTransform
+		mapping map[string]string
+		reason  string
+	}{
+		{
+			name:    "every key by default",
+			mapping: map[string]string{"ca.crt": "ca.crt", "tls.crt": "tls.crt", "tls.key": "tls.key"},
+		},
+		{
+			name:    "include",
+			keys:    &utilsv1alpha1.KeyTransform{Include: []string{"tls.*"}},
+			mapping: map[string]string{"tls.crt": "tls.crt", "tls.key": "tls.key"},
+		},
+		{
+			name:    "exclude",
+			keys:    &utilsv1alpha1.KeyTransform{Exclude: []string{"*.key"}},
+			mapping: map[string]string{"ca.crt": "ca.crt", "tls.crt": "tls.crt"},
+		},
+		{
+			name: "renamed keys are included",
+			keys: &utilsv1alpha1.KeyTransform{
+				Include: []string{"tls.crt"},
+				Rename:  map[string]string{"ca.crt": "ca.pem"},
+			},
+			mapping: map[string]string{"ca.crt": "ca.pem", "tls.crt": "tls.crt"},
+		},
+		{
+			name:   "missing included key",
+			keys:   &utilsv1alpha1.KeyTransform{Include: []string{"tls.crt", "password"}},
+			reason: "MissingKey",
+		},
+		{
+			name:   "missing renamed key",
+			keys:   &utilsv1alpha1.KeyTransform{Rename: map[string]string{"password": "pass"}},
+			reason: "MissingKey",
+		},
+		{
+			name:   "rename collision",
+			keys:   &utilsv1alpha1.KeyTransform{Rename: map[string]string{"ca.crt": "tls.crt"}},
+			reason: "InvalidTransform",
+		},
+		{
+			name:   "invalid pattern",
+			keys:   &utilsv1alpha1.KeyTransform{Exclude: []string{"[tls"}},
+			reason: "InvalidTransform",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mapping, err := keyMapping(sourceKeys, tt.keys)
+			if tt.reason != "" {
+				if err == nil || ReasonFor(err) != tt.reason {
+					t.Fatalf("keyMapping() error = %v, want reason %s", err, tt.reason)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("keyMapping() error = %v", err)
+			}
+			if !reflect.DeepEqual(mapping, tt.mapping) {
+				t.Errorf("keyMapping() = %v, want %v", mapping, tt.mapping)
+			}
+		})
+	}
+}
+
+func TestSecretDesiredReportsMissingKey(t *testing.T) {
+	source := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "source", Namespace: "default"},
+		Data:       map[string][]byte{"username": []byte("admin")},
+	}
+	rep := &Replication{Transform: utilsv1alpha1.ReplicatedResourceTransform{
+		Keys: &utilsv1alpha1.KeyTransform{Include: []string{"username", "password"}},
+	}}
+
+	_, err := (&SecretReplicator{}).Desired(rep, source)
+	if want := `Key "password" is missing from the source`; err == nil || err.Error() != want {
+		t.Errorf("Desired() error = %v, want %s", err, want)
+	}
+}
+
+func TestSecretApplyUpdatesChangedTransformUnderReportOnly(t *testing.T) {
+	source := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "source", Namespace: "default", ResourceVersion: "1"},
+		Data:       map[string][]byte{"username": []byte("admin"), "password": []byte("secret")},
+	}
+	c := fake.NewClientBuilder().Build()
+	r := &SecretReplicator{}
+	rep := &Replication{
+		Source:      types.NamespacedName{Namespace: "default", Name: "source"},
+		Destination: types.NamespacedName{Namespace: "apps", Name: "copy"},
+		Transform: utilsv1alpha1.ReplicatedResourceTransform{
+			Keys: &utilsv1alpha1.KeyTransform{Include: []string{"username"}},
+		},
+		DriftPolicy: utilsv1alpha1.DriftReportOnly,
+	}
+	apply := func() Result {
+		t.Helper()
+		desired, err := r.Desired(rep, source)
+		if err != nil {
+			t.Fatalf("Desired() error = %v", err)
+		}
+		result, err := r.Apply(context.Background(), c, rep, desired)
+		if err != nil {
+			t.Fatalf("Apply() error = %v", err)
+		}
+		return result
+	}
+	apply()
+
+	rep.Transform.Keys = &utilsv1alpha1.KeyTransform{Rename: map[string]string{"password": "pass"}}
+	result := apply()
+	if result.Operation != controllerutil.OperationResultUpdated {
+		t.Errorf("Operation = %s, want %s", result.Operation, controllerutil.OperationResultUpdated)
+	}
+	if result.Drifted {
+		t.Error("Drifted = true, want false")
+	}
+	dest := &corev1.Secret{}
+	if err := c.Get(context.Background(), rep.Destination, dest); err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]byte{"username": []byte("admin"), "pass": []byte("secret")}
+	if !reflect.DeepEqual(dest.Data, want) {
+		t.Errorf("destination data = %v, want %v", dest.Data, want)
+	}
+}
@@ -0,0 +1,178 @@
+/*
+   Copyright 2021-2022 Russell Sim <russell.sim@gmail.com>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package replicator
+
+import (
+	"context"
+	"fmt"
+	"github.com/go-logr/logr"
+	"github.com/russell/resource-replication-operator/replicator/common"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"slices"
+)
+
+// serverManagedFields are the top-level fields that are never copied from
+// the source, the destination gets its own metadata and the status is
+// owned by whatever controller manages the kind.
+var serverManagedFields = map[string]bool{
+	"apiVersion": true,
+	"kind":       true,
+	"metadata":   true,
+	"status":     true,
+}
+
+// UnstructuredReplicator replicates any namespaced kind known to the API
+// server by copying the top-level fields of the source object.
+type UnstructuredReplicator struct {
+	Log logr.Logger
+	GVK schema.GroupVersionKind
+}
+
+func (r *UnstructuredReplicator) NewObject() client.Object {
+	obj := &unstructured.Unstructured{}
+	obj.SetGroupVersionKind(r.GVK)
+	return obj
+}
+
+func (r *UnstructuredReplicator) Fetch(ctx context.Context, c client.Client, key types.NamespacedName) (client.Object, error) {
+	mapping, err := c.RESTMapper().RESTMapping(r.GVK.GroupKind(), r.GVK.Version)
+	if err != nil {
+		return nil, fmt.Errorf("Unsupported kind %s: %w", r.GVK, err)
+	}
+	if mapping.Scope.Name() != meta.RESTScopeNameNamespace {
+		return nil, fmt.Errorf("Unsupported kind %s: only namespaced kinds can be replicated", r.GVK)
+	}
+
+	source := r.NewObject()
+	if err := fetch(ctx, c, key, source, r.GVK.Kind); err != nil {
+		return nil, err
+	}
+	return source, nil
+}
+
+func (r *UnstructuredReplicator) Desired(rep *Replication, source client.Object) (client.Object, error) {
+	src := source.(*unstructured.Unstructured)
+	if rep.Transform.Keys != nil || len(rep.Transform.Template) > 0 {
+		return nil, newError("InvalidTransform", "Transforms are not supported for %s", r.GVK.Kind)
+	}
+	desired := &unstructured.Unstructured{Object: map[string]interface{}{}}
+	desired.SetGroupVersionKind(r.GVK)
+	objMeta := desiredMeta(rep, source)
+	desired.SetName(objMeta.Name)
+	desired.SetNamespace(objMeta.Namespace)
+	desired.SetOwnerReferences(objMeta.OwnerReferences)
+	desired.SetLabels(objMeta.Labels)
+	for _, field := range replicatedFields(src, rep.Fields) {
+		if value, ok := src.Object[field]; ok {
+			desired.Object[field] = runtime.DeepCopyJSONValue(value)
+		}
+	}
+	objMeta.Annotations[common.ReplicatedHashAnnotation] = contentHash(content(desired))
+	desired.SetAnnotations(objMeta.Annotations)
+	return desired, nil
+}
+
+func (r *UnstructuredReplicator) Apply(ctx context.Context, c client.Client, rep *Replication, desired client.Object) (Result, error) {
+	want := desired.(*unstructured.Unstructured)
+	dest := r.NewObject().(*unstructured.Unstructured)
+	dest.SetName(want.GetName())
+	dest.SetNamespace(want.GetNamespace())
+
+	return createOrUpdate(ctx, c, rep, want, dest, func() bool {
+		// The API server may default fields, so the destination is in
+		// sync as long as it contains everything that was replicated.
+		return containsJSON(content(dest), content(want))
+	}, func() {
+		r.Log.Info(fmt.Sprintf("Updating %s", r.GVK.Kind), "destination", client.ObjectKeyFromObject(dest).String())
+		for _, field := range replicatedFields(want, rep.Fields) {
+			dest.Object[field] = runtime.DeepCopyJSONValue(want.Object[field])
+		}
+		// Remove configured fields that are no longer set on the source
+		for _, field := range rep.Fields {
+			if _, ok := want.Object[field]; !ok && !serverManagedFields[field] {
+				delete(dest.Object, field)
+			}
+		}
+	})
+}
+
+// content returns the top-level fields of obj that aren't managed by the
+// API server.
+func content(obj *unstructured.Unstructured) map[string]interface{} {
+	fields := make(map[string]interface{})
+	for field, value := range obj.Object {
+		if !serverManagedFields[field] {
+			fields[field] = value
+		}
+	}
+	return fields
+}
+
+// containsJSON reports whether actual contains every value in expected,
+// maps in actual may have additional keys while lists must have the same
+// length.
+func containsJSON(actual, expected interface{}) bool {
+	switch expected := expected.(type) {
+	case map[string]interface{}:
+		actual, ok := actual.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		for k, v := range expected {
+			if !containsJSON(actual[k], v) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		actual, ok := actual.([]interface{})
+		if !ok || len(actual) != len(expected) {
+			return false
+		}
+		for i := range expected {
+			if !containsJSON(actual[i], expected[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return equality.Semantic.DeepEqual(actual, expected)
+	}
+}
+
+// replicatedFields returns the top-level fields of obj that should be
+// copied, either the configured fields or every field, minus the fields
+// managed by the API server.
+func replicatedFields(obj *unstructured.Unstructured, configured []string) []string {
+	var fields []string
+	for field := range obj.Object {
+		if serverManagedFields[field] {
+			continue
+		}
+		if len(configured) > 0 && !slices.Contains(configured, field) {
+			continue
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
@@ -0,0 +1,159 @@
+/*
+   Copyright 2021-2022 Russell Sim <russell.sim@gmail.com>
+
+   Licensed under the Apache License, Version 2.0 (the "License");
+   you may not use this file except in compliance with the License.
+   You may obtain a copy of the License at
+
+http://www.apache.org/licenses/LICENSE-2.0
+
+   Unless required by applicable law or agreed to in writing, software
+   distributed under the License is distributed on an "AS IS" BASIS,
+   WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+   See the License for the specific language governing permissions and
+   limitations under the License.
+*/
+
+package replicator
+
+import (
+	"context"
+	"testing"
+
+	utilsv1alpha1 "github.com/russell/resource-replication-operator/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+func TestContainsJSON(t *testing.T) {
+	replicated := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"podSelector": map[string]interface{}{},
+			"ingress": []interface{}{
+				map[string]interface{}{"ports": []interface{}{map[string]interface{}{"port": int64(80)}}},
+			},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		actual interface{}
+		want   bool
+	}{
+		{
+			name:   "identical",
+			actual: replicated,
+			want:   true,
+		},
+		{
+			name: "defaulted by the API server",
+			actual: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"podSelector": map[string]interface{}{},
+					"policyTypes": []interface{}{"Ingress"},
+					"ingress": []interface{}{
+						map[string]interface{}{"ports": []interface{}{map[string]interface{}{"port": int64(80), "protocol": "TCP"}}},
+					},
+				},
+			},
+			want: true,
+		},
+		{
+			name: "modified value",
+			actual: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"podSelector": map[string]interface{}{},
+					"ingress": []interface{}{
+						map[string]interface{}{"ports": []interface{}{map[string]interface{}{"port": int64(8080)}}},
+					},
+				},
+			},
+			want: false,
+		},
+		{
+			name: "removed list item",
+			actual: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"podSelector": map[string]interface{}{},
+					"ingress":     []interface{}{},
+				},
+			},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := containsJSON(tt.actual, replicated); got != tt.want {
+				t.Errorf("containsJSON() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyUpdatesChangedContentUnderReportOnly(t *testing.T) {
+	r := &UnstructuredReplicator{GVK: schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}}
+	source := &unstructured.Unstructured{Object: map[string]interface{}{
+		"data":       map[string]interface{}{"key": "value"},
+		"binaryData": map[string]interface{}{"blob": "YmxvYg=="},
+	}}
+	source.SetGroupVersionKind(r.GVK)
+	source.SetNamespace("default")
+	source.SetName("source")
+	source.SetResourceVersion("1")
+
+	c := fake.NewClientBuilder().Build()
+	rep := &Replication{
+		Source:      types.NamespacedName{Namespace: "default", Name: "source"},
+		Destination: types.NamespacedName{Namespace: "apps", Name: "copy"},
+		Fields:      []string{"data"},
+		Labels:      map[string]string{"app": "test"},
+		DriftPolicy: utilsv1alpha1.DriftReportOnly,
+	}
+	apply := func() Result {
+		t.Helper()
+		desired, err := r.Desired(rep, source)
+		if err != nil {
+			t.Fatalf("Desired() error = %v", err)
+		}
+		result, err := r.Apply(context.Background(), c, rep, desired)
+		if err != nil {
+			t.Fatalf("Apply() error = %v", err)
+		}
+		return result
+	}
+	apply()
+
+	// Replicating another field changes the desired content while the
+	// source is unchanged, which isn't drift.
+	rep.Fields = []string{"data", "binaryData"}
+	result := apply()
+	if result.Operation != controllerutil.OperationResultUpdated {
+		t.Errorf("Operation = %s, want %s", result.Operation, controllerutil.OperationResultUpdated)
+	}
+	if result.Drifted {
+		t.Error("Drifted = true, want false")
+	}
+	dest := r.NewObject().(*unstructured.Unstructured)
+	if err := c.Get(context.Background(), rep.Destination, dest); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := dest.Object["binaryData"]; !ok {
+		t.Errorf("destination = %v, want binaryData to be replicated", dest.Object)
+	}
+
+	// Modifying the destination is drift, which is only reported.
+	unstructured.SetNestedField(dest.Object, "modified", "data", "key")
+	if err := c.Update(context.Background(), dest); err != nil {
+		t.Fatal(err)
+	}
+	result = apply()
+	if result.Operation != controllerutil.OperationResultNone {
+		t.Errorf("Operation = %s, want %s", result.Operation, controllerutil.OperationResultNone)
+	}
+	if !result.Drifted {
+		t.Error("Drifted = false, want true")
+	}
+}